@@ -0,0 +1,293 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/v3/cpu"
+)
+
+// Placement policies of the -pin option. Any other value is an explicit list of CPUs.
+const (
+	PinCompact = "compact"
+	PinScatter = "scatter"
+	PinNode    = "node"
+)
+
+// Placement is the set of CPUs each worker is pinned to
+type Placement [][]int
+
+// cpuTopology returns the socket, core and NUMA node of each CPU. If the NUMA
+// topology is not available, all the CPUs are considered in node 0.
+func cpuTopology() ([]NumaCPU, error) {
+	cpuinfo, err := cpu.InfoWithContext(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	if res := GetNumaCPUs(cpuinfo); len(res) > 0 {
+		return res, nil
+	}
+	res := make([]NumaCPU, 0, runtime.NumCPU())
+	for c := 0; c < runtime.NumCPU(); c++ {
+		res = append(res, NumaCPU{CPU: c, Socket: "0", CoreID: strconv.Itoa(c)})
+	}
+	return res, nil
+}
+
+// NewPlacement assigns CPUs to the workers according to the pinning policy:
+//   - compact: one CPU per worker, filling the cores (and their SMT siblings) of a node before the next one
+//   - scatter: one CPU per worker, round robin over the nodes, one thread per core first
+//   - node: all the CPUs of a node per worker, round robin over the nodes
+//   - explicit list (e.g. 0,2,4-7): one CPU per worker, round robin over the list
+//
+// With compact and scatter, only the first threads CPUs are used, since the Go
+// runtime does not run more threads at the same time.
+func NewPlacement(policy string, topo []NumaCPU, workers, threads int) (Placement, error) {
+
+	if len(topo) == 0 {
+		return nil, fmt.Errorf("no CPU topology available")
+	}
+	var sets [][]int
+	switch policy {
+	case PinCompact, PinScatter:
+		order := compactOrder(topo)
+		if policy == PinScatter {
+			order = scatterOrder(topo)
+		}
+		for _, c := range order[:min(threads, len(order))] {
+			sets = append(sets, []int{c})
+		}
+	case PinNode:
+		nodes := map[int][]int{}
+		for _, c := range compactOrder(topo) {
+			n := cpuAt(topo, c).Node
+			nodes[n] = append(nodes[n], c)
+		}
+		for _, n := range sortedKeys(nodes) {
+			sets = append(sets, nodes[n])
+		}
+	default:
+		list, err := parseCPUList(policy)
+		if err != nil {
+			return nil, err
+		}
+		for _, c := range list {
+			if !slices.ContainsFunc(topo, func(x NumaCPU) bool { return x.CPU == c }) {
+				return nil, fmt.Errorf("CPU %d does not exist or is not available", c)
+			}
+			sets = append(sets, []int{c})
+		}
+	}
+
+	res := make(Placement, workers)
+	for i := range res {
+		res[i] = sets[i%len(sets)]
+	}
+	return res, nil
+}
+
+// allowedCPUs restricts the topology to a list of CPUs
+func allowedCPUs(topo []NumaCPU, cpus []int) []NumaCPU {
+	return slices.DeleteFunc(slices.Clone(topo), func(x NumaCPU) bool { return !slices.Contains(cpus, x.CPU) })
+}
+
+// CPUs returns all the CPUs used by the placement
+func (p Placement) CPUs() []int {
+	var res []int
+	for _, set := range p {
+		for _, c := range set {
+			if !slices.Contains(res, c) {
+				res = append(res, c)
+			}
+		}
+	}
+	slices.Sort(res)
+	return res
+}
+
+// compactOrder sorts the CPUs by node, socket, core, so that SMT siblings are adjacent
+func compactOrder(topo []NumaCPU) []int {
+	cpus := slices.Clone(topo)
+	slices.SortFunc(cpus, func(a, b NumaCPU) int {
+		return cmp.Or(
+			cmp.Compare(a.Node, b.Node),
+			compareID(a.Socket, b.Socket),
+			compareID(a.CoreID, b.CoreID),
+			cmp.Compare(a.CPU, b.CPU),
+		)
+	})
+	res := make([]int, len(cpus))
+	for i, c := range cpus {
+		res[i] = c.CPU
+	}
+	return res
+}
+
+// scatterOrder spreads the CPUs over the nodes. In each node, the first thread
+// of each core comes before the SMT siblings.
+func scatterOrder(topo []NumaCPU) []int {
+
+	// Rank each CPU among the siblings of its core
+	rank := map[int]int{}
+	cores := map[string]int{}
+	for _, c := range compactOrder(topo) {
+		x := cpuAt(topo, c)
+		key := fmt.Sprintf("%d/%s/%s", x.Node, x.Socket, x.CoreID)
+		rank[c] = cores[key]
+		cores[key]++
+	}
+
+	// Sort the CPUs of each node by sibling rank, then round robin over the nodes
+	nodes := map[int][]int{}
+	for _, c := range compactOrder(topo) {
+		n := cpuAt(topo, c).Node
+		nodes[n] = append(nodes[n], c)
+	}
+	keys := sortedKeys(nodes)
+	for _, n := range keys {
+		slices.SortStableFunc(nodes[n], func(a, b int) int { return cmp.Compare(rank[a], rank[b]) })
+	}
+	var res []int
+	for i := 0; len(res) < len(topo); i++ {
+		for _, n := range keys {
+			if i < len(nodes[n]) {
+				res = append(res, nodes[n][i])
+			}
+		}
+	}
+	return res
+}
+
+// cpuAt returns the location of a CPU in the topology
+func cpuAt(topo []NumaCPU, c int) NumaCPU {
+	return topo[slices.IndexFunc(topo, func(x NumaCPU) bool { return x.CPU == c })]
+}
+
+// compareID compares socket or core identifiers, numerically if possible
+func compareID(a, b string) int {
+	x, errx := strconv.Atoi(a)
+	y, erry := strconv.Atoi(b)
+	if errx == nil && erry == nil {
+		return cmp.Compare(x, y)
+	}
+	return strings.Compare(a, b)
+}
+
+// sortedKeys returns the sorted keys of a map indexed by integers
+func sortedKeys[T any](m map[int]T) []int {
+	res := make([]int, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	slices.Sort(res)
+	return res
+}
+
+// parseCPUList parses a list of CPUs such as 0,2,4-7
+func parseCPUList(s string) ([]int, error) {
+	var res []int
+	for _, item := range strings.Split(s, ",") {
+		lo, hi, isRange := strings.Cut(strings.TrimSpace(item), "-")
+		a, err := strconv.Atoi(lo)
+		if err != nil || a < 0 {
+			return nil, fmt.Errorf("invalid CPU list %q (compact, scatter, node or list such as 0,2,4-7 expected)", s)
+		}
+		b := a
+		if isRange {
+			if b, err = strconv.Atoi(hi); err != nil || b < a {
+				return nil, fmt.Errorf("invalid CPU range %q in %q", item, s)
+			}
+		}
+		for c := a; c <= b; c++ {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// formatCPUList formats a sorted list of CPUs with ranges (e.g. 0,2,4-7)
+func formatCPUList(cpus []int) string {
+	var b strings.Builder
+	for i := 0; i < len(cpus); {
+		j := i
+		for j+1 < len(cpus) && cpus[j+1] == cpus[j]+1 {
+			j++
+		}
+		if b.Len() > 0 {
+			b.WriteByte(',')
+		}
+		if j > i {
+			fmt.Fprintf(&b, "%d-%d", cpus[i], cpus[j])
+		} else {
+			fmt.Fprintf(&b, "%d", cpus[i])
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+// newPinning computes the placement of the workers for the -pin option
+func newPinning() (Placement, error) {
+	if *flagPin == "" {
+		return nil, nil
+	}
+	if !affinitySupported {
+		return nil, fmt.Errorf("CPU pinning (-pin) is not supported on %s", runtime.GOOS)
+	}
+	topo, err := cpuTopology()
+	if err != nil {
+		return nil, err
+	}
+
+	// The workers are only placed on the CPUs the process is restricted to
+	// (e.g. by -cpuset in the NUMA, CPU sweep and SMT benchmarks)
+	allowed, err := processAffinity()
+	if err != nil {
+		return nil, err
+	}
+	res, err := NewPlacement(*flagPin, allowedCPUs(topo, allowed), *flagWorkers, *flagThreads)
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("Pinning %d workers (%s) on CPUs %s", *flagWorkers, *flagPin, formatCPUList(res.CPUs()))
+	return res, nil
+}
+
+// pinWorkers returns the hook pinning each worker to its CPUs (nil without
+// placement). The goroutine of the worker must stay on the same OS thread.
+func pinWorkers(p Placement) func(id int) {
+	if p == nil {
+		return nil
+	}
+	return func(id int) {
+		if len(p[id]) == 0 {
+			return
+		}
+		runtime.LockOSThread()
+		if err := setAffinity(p[id]); err != nil {
+			log.Printf("Worker %d: cannot set CPU affinity: %v", id, err)
+		}
+	}
+}
+
+// restrictCPUs restricts the process to a list of CPUs for the -cpuset option
+func restrictCPUs(list string) error {
+	if !affinitySupported {
+		return fmt.Errorf("CPU sets (-cpuset) are not supported on %s", runtime.GOOS)
+	}
+	cpus, err := parseCPUList(list)
+	if err != nil {
+		return err
+	}
+	if err := setProcessAffinity(cpus); err != nil {
+		return err
+	}
+	log.Printf("Restricted to CPUs %s", list)
+	return nil
+}
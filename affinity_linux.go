@@ -0,0 +1,70 @@
+//go:build linux
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"golang.org/x/sys/unix"
+)
+
+// affinitySupported is true if the OS threads can be pinned to CPUs
+const affinitySupported = true
+
+// setAffinity restricts the current OS thread to a set of CPUs. The calling
+// goroutine must be locked to its OS thread.
+func setAffinity(cpus []int) error {
+	set := newCPUSet(cpus)
+	return unix.SchedSetaffinity(0, &set)
+}
+
+// setProcessAffinity restricts all the OS threads of the process to a set of
+// CPUs. The threads created later inherit the affinity of their creator. The
+// list of threads is scanned twice in case a thread was created concurrently.
+func setProcessAffinity(cpus []int) error {
+	set := newCPUSet(cpus)
+	for pass := 0; pass < 2; pass++ {
+		tasks, err := os.ReadDir("/proc/self/task")
+		if err != nil {
+			return err
+		}
+		for _, t := range tasks {
+			tid, err := strconv.Atoi(t.Name())
+			if err != nil {
+				continue
+			}
+			if err := unix.SchedSetaffinity(tid, &set); err != nil {
+				return fmt.Errorf("thread %d: %w", tid, err)
+			}
+		}
+	}
+	return nil
+}
+
+// processAffinity returns the CPUs the current OS thread (and therefore the
+// process, see setProcessAffinity) is allowed to run on
+func processAffinity() ([]int, error) {
+	var set unix.CPUSet
+	if err := unix.SchedGetaffinity(0, &set); err != nil {
+		return nil, err
+	}
+	var res []int
+	for c := 0; len(res) < set.Count(); c++ {
+		if set.IsSet(c) {
+			res = append(res, c)
+		}
+	}
+	return res, nil
+}
+
+// newCPUSet builds the affinity mask of a list of CPUs
+func newCPUSet(cpus []int) unix.CPUSet {
+	var set unix.CPUSet
+	set.Zero()
+	for _, c := range cpus {
+		set.Set(c)
+	}
+	return set
+}
@@ -0,0 +1,23 @@
+//go:build !linux
+
+package main
+
+import "errors"
+
+// affinitySupported is true if the OS threads can be pinned to CPUs
+const affinitySupported = false
+
+// setAffinity is not supported on this platform
+func setAffinity(cpus []int) error {
+	return errors.ErrUnsupported
+}
+
+// processAffinity is not supported on this platform
+func processAffinity() ([]int, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// setProcessAffinity is not supported on this platform
+func setProcessAffinity(cpus []int) error {
+	return errors.ErrUnsupported
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+// testTopology is a machine with 2 nodes of 2 cores with 2 SMT threads each.
+// Siblings are numbered like on Linux (core i has CPUs i and i+4).
+func testTopology() []NumaCPU {
+	var res []NumaCPU
+	for c := 0; c < 8; c++ {
+		core := c % 4
+		res = append(res, NumaCPU{CPU: c, Socket: "0", CoreID: string(rune('0' + core)), Node: core / 2})
+	}
+	return res
+}
+
+func TestNewPlacement(t *testing.T) {
+	topo := testTopology()
+	tests := []struct {
+		policy   string
+		threads  int
+		expected Placement
+	}{
+		{PinCompact, 4, Placement{{0}, {4}, {1}, {5}, {0}}},
+		{PinScatter, 4, Placement{{0}, {2}, {1}, {3}, {0}}},
+		{PinScatter, 8, Placement{{0}, {2}, {1}, {3}, {4}}},
+		{PinNode, 8, Placement{{0, 4, 1, 5}, {2, 6, 3, 7}, {0, 4, 1, 5}, {2, 6, 3, 7}, {0, 4, 1, 5}}},
+		{"6,1-2", 8, Placement{{6}, {1}, {2}, {6}, {1}}},
+	}
+	for _, tt := range tests {
+		res, err := NewPlacement(tt.policy, topo, 5, tt.threads)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.policy, err)
+		}
+		if !slices.EqualFunc(res, tt.expected, slices.Equal) {
+			t.Errorf("%s: placement=%v, expected %v", tt.policy, res, tt.expected)
+		}
+	}
+
+	for _, policy := range []string{"foo", "3-1", "9"} {
+		if _, err := NewPlacement(policy, topo, 5, 8); err == nil {
+			t.Errorf("%s: error expected", policy)
+		}
+	}
+}
+
+func TestFormatCPUList(t *testing.T) {
+	if s := formatCPUList([]int{0, 2, 4, 5, 6, 7, 9}); s != "0,2,4-7,9" {
+		t.Errorf("formatCPUList=%s", s)
+	}
+	if l, err := parseCPUList("0,2,4-7,9"); err != nil || !slices.Equal(l, []int{0, 2, 4, 5, 6, 7, 9}) {
+		t.Errorf("parseCPUList=%v %v", l, err)
+	}
+}
+
+func TestAllowedCPUs(t *testing.T) {
+
+	// Node 1 only, like in the NUMA benchmark: the workers stay in the node
+	topo := allowedCPUs(testTopology(), []int{2, 3, 6, 7})
+	res, err := NewPlacement(PinCompact, topo, 4, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cpus := res.CPUs(); !slices.Equal(cpus, []int{2, 3, 6, 7}) {
+		t.Errorf("placement on CPUs %v, expected node 1", cpus)
+	}
+	if _, err := NewPlacement("0,2", topo, 2, 2); err == nil {
+		t.Error("CPU outside of the allowed CPUs should be rejected")
+	}
+}
+
+func TestProcessAffinity(t *testing.T) {
+	if !affinitySupported {
+		t.Skip("CPU affinity is not supported")
+	}
+	cpus, err := processAffinity()
+	if err != nil || len(cpus) == 0 {
+		t.Errorf("cpus=%v, err=%v", cpus, err)
+	}
+}
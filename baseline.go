@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// referenceBaseline contains the scores of the reference machine, measured
+// with the standard benchmark of the current version
+//
+//go:embed reference.json
+var referenceBaseline []byte
+
+// Baseline contains the scores of a reference machine. Like in the standard
+// benchmark, the score is the maximum throughput of the iterations.
+type Baseline struct {
+	Version string  `json:"version"`
+	Machine string  `json:"machine"`
+	Single  float64 `json:"single"`
+	Multi   float64 `json:"multi"`
+}
+
+// Score is the throughput normalized against the reference machine
+type Score struct {
+	Reference Baseline `json:"reference"`
+	Single    float64  `json:"single"`
+	Multi     float64  `json:"multi"`
+}
+
+// LoadBaseline reads the scores of the reference machine. The file is either a
+// baseline JSON document, or a result file produced by the standard benchmark on
+// the reference machine. Without file, the embedded reference machine is used.
+func LoadBaseline(filename string) (*Baseline, error) {
+	if filename == "" {
+		return DecodeBaseline("embedded reference", referenceBaseline)
+	}
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	return DecodeBaseline(filename, b)
+}
+
+// DecodeBaseline decodes a baseline JSON document or a result file. The
+// baseline must have been produced by the same version of the benchmark.
+func DecodeBaseline(filename string, b []byte) (*Baseline, error) {
+
+	// Try a baseline document first, then a result file
+	var res Baseline
+	if err := json.Unmarshal(b, &res); err != nil || res.Single <= 0.0 || res.Multi <= 0.0 {
+		rf, err := DecodeResult(bytes.NewReader(b))
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+		if mix, err := rf.Mix(); err != nil || mix != "" {
+			return nil, fmt.Errorf("%s: the baseline must be measured with the canonical workload mix", filename)
+		}
+		m := rf.Map()
+		res = Baseline{
+			Version: rf.Header.Version,
+			Machine: rf.Header.Host,
+			Single:  bench.ComputeStat(m[1]).Max,
+			Multi:   bench.ComputeStat(m[multiWorkers(m)]).Max,
+		}
+		if res.Single <= 0.0 || res.Multi <= 0.0 {
+			return nil, fmt.Errorf("%s: single-threaded and multi-threaded results are expected", filename)
+		}
+	}
+
+	// Scores measured with different versions must not be compared
+	if res.Version != Version {
+		return nil, fmt.Errorf("%s: baseline of version %q cannot be used with version %s", filename, res.Version, Version)
+	}
+	return &res, nil
+}
+
+// NewScore normalizes the results against the baseline
+func NewScore(base *Baseline, m ResultMap, workers int) *Score {
+	return &Score{
+		Reference: *base,
+		Single:    bench.ComputeStat(m[1]).Max / base.Single,
+		Multi:     bench.ComputeStat(m[workers]).Max / base.Multi,
+	}
+}
+
+// displayScore displays the normalized score
+func displayScore(s *Score) {
+	log.Printf("Normalized score (reference: %s, version %s)", s.Reference.Machine, s.Reference.Version)
+	log.Printf("    Single thread: %.3f", s.Single)
+	log.Printf("     Multi-thread: %.3f", s.Multi)
+	log.Print()
+}
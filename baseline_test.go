@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDecodeBaseline(t *testing.T) {
+	tests := []struct {
+		content       string
+		single, multi float64
+	}{
+		{`{"version": "` + Version + `", "machine": "ref", "single": 100, "multi": 800}`, 100.0, 800.0},
+		{`{"type":"header","format":2,"version":"` + Version + `","host":"ref"}` + "\n" +
+			`{"type":"iteration","workers":1,"throughput":90}` + "\n" +
+			`{"type":"iteration","workers":1,"throughput":100}` + "\n" +
+			`{"type":"iteration","workers":8,"throughput":800}` + "\n", 100.0, 800.0},
+		{`{"version": "5.0", "machine": "ref", "single": 100, "multi": 800}`, 0.0, 0.0},
+		{`{"version": "` + Version + `", "machine": "ref", "single": 100}`, 0.0, 0.0},
+		{`{"type":"header","format":99,"version":"` + Version + `"}`, 0.0, 0.0},
+		{"1 100.0\n8 800.0\n", 0.0, 0.0},
+		{`{"type":"header","format":2,"version":"` + Version + `","host":"ref"}` + "\n" +
+			`{"type":"iteration","workers":1,"throughput":100}` + "\n", 0.0, 0.0},
+		{`{"type":"header","format":2,"version":"` + Version + `","host":"ref"}` + "\n" +
+			`{"type":"iteration","workers":1,"throughput":100,"mix":"json=1"}` + "\n" +
+			`{"type":"iteration","workers":8,"throughput":800,"mix":"json=1"}` + "\n", 0.0, 0.0},
+	}
+	for i, tt := range tests {
+		base, err := DecodeBaseline("test", []byte(tt.content))
+		if tt.single == 0.0 {
+			if err == nil {
+				t.Errorf("%d: baseline should be rejected: %+v", i, base)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%d: %v", i, err)
+			continue
+		}
+		if base.Single != tt.single || base.Multi != tt.multi || base.Machine != "ref" {
+			t.Errorf("%d: unexpected baseline: %+v", i, base)
+		}
+	}
+}
+
+func TestLoadBaseline(t *testing.T) {
+
+	// The embedded reference machine must be measured with the current version
+	base, err := LoadBaseline("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if base.Version != Version || base.Single <= 0.0 || base.Multi <= 0.0 {
+		t.Errorf("unexpected embedded baseline: %+v", base)
+	}
+
+	name := filepath.Join(t.TempDir(), "baseline.json")
+	if err := os.WriteFile(name, []byte(`{"version": "4.0", "single": 1, "multi": 1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBaseline(name); err == nil || !strings.Contains(err.Error(), name) {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestNewScore(t *testing.T) {
+	base := &Baseline{Version: Version, Machine: "ref", Single: 100.0, Multi: 800.0}
+	tests := []struct {
+		m             ResultMap
+		single, multi float64
+	}{
+		{ResultMap{1: {90.0, 100.0}, 8: {800.0}}, 1.0, 1.0},
+		{ResultMap{1: {150.0, 120.0}, 8: {400.0, 200.0}}, 1.5, 0.5},
+		{ResultMap{1: {50.0}, 16: {1600.0}}, 0.5, 2.0},
+	}
+	for _, tt := range tests {
+		workers := multiWorkers(tt.m)
+		s := NewScore(base, tt.m, workers)
+		if s.Single != tt.single || s.Multi != tt.multi || s.Reference != *base {
+			t.Errorf("score of %v = %+v, expected %v/%v", tt.m, s, tt.single, tt.multi)
+		}
+	}
+}
@@ -0,0 +1,314 @@
+// Package bench is the engine of cpubench1a: it runs a set of workloads in
+// parallel workers, injects the transactions, and measures the throughput,
+// the latency, and the cost of each workload.
+//
+// The workloads run by each worker are supplied by the caller in the
+// configuration. The canonical workloads of cpubench1a, with their datasets,
+// are in the workloads subpackage.
+package bench
+
+import (
+	"context"
+	"errors"
+	"log"
+	"runtime"
+	"time"
+)
+
+// Default values of the configuration
+const (
+	DefaultDuration  = 60 * time.Second
+	DefaultSteadyCoV = 5.0
+)
+
+// Config is the configuration of a benchmark. The zero value of each field
+// (except Workloads, which is mandatory) selects a default.
+type Config struct {
+
+	// Number of Go threads available to the benchmark. Default is the current
+	// GOMAXPROCS value. It should not exceed GOMAXPROCS, unless SetMaxProcs is set.
+	Threads int
+
+	// SetMaxProcs sets GOMAXPROCS to Threads during the benchmark, and restores
+	// it afterwards. GOMAXPROCS is process-wide: it also applies to the other
+	// goroutines of the program, so it must not be set by concurrent benchmarks,
+	// nor by a program which does not dedicate the process to the benchmark.
+	SetMaxProcs bool
+
+	// Number of workers. Default is 4*threads.
+	Workers int
+
+	// Duration of the measurement window of each iteration. Default is DefaultDuration.
+	Duration time.Duration
+
+	// Number of iterations run by Run. Default is 1.
+	Iterations int
+
+	// Duration of the warm-up phase of each iteration (maximum duration with
+	// Steady, which is a quarter of Duration by default)
+	Warmup time.Duration
+
+	// Start the measurement once the throughput is stable: its coefficient
+	// of variation (in %) is below SteadyCoV (default is DefaultSteadyCoV).
+	Steady    bool
+	SteadyCoV float64
+
+	// Throughput drop (in %) below the median of an iteration reported in
+	// the timeline (0 to disable)
+	TimelineDrop float64
+
+	// Injection policy. Default is InjectSaturation.
+	Injector Injector
+
+	// Target throughput of the rate limited injectors (InjectTicker and InjectPoisson)
+	TPS int
+
+	// Workloads builds the algorithms run by a worker for each transaction.
+	// It is called once per worker, in the goroutine of the worker.
+	Workloads func() []Workload
+
+	// Optional transaction types, made of the workloads. Without transaction
+	// types, each transaction runs all the workloads according to their weight.
+	Transactions []TransactionType
+
+	// Seed of the random generators drawing the transaction types (one per worker)
+	Seed uint64
+
+	// WorkerStart is optionally called in the goroutine of each worker
+	// before its initialization (e.g. to pin the worker to CPUs).
+	WorkerStart func(id int)
+
+	// Logger receives the progress messages. Default is no logging.
+	Logger *log.Logger
+}
+
+// Iteration is the result of a single benchmark iteration
+type Iteration struct {
+	Start        time.Time
+	End          time.Time
+	Warmup       time.Duration
+	Transactions int
+	Throughput   float64
+	Breakdown    []WorkloadCost
+	Latency      *Latency
+	Timeline     *Timeline
+	Types        []TypeStat
+}
+
+// Result is the result of a benchmark. Its throughput is the maximum
+// throughput of the iterations.
+type Result struct {
+	Threads    int
+	Workers    int
+	Iterations []*Iteration
+	Throughput float64
+	Stat       Stat
+}
+
+// ErrNoWorkloads is returned when the configuration does not define the workloads
+var ErrNoWorkloads = errors.New("bench: no workloads")
+
+// Run runs the iterations of a benchmark. When the context is cancelled, the
+// current iteration is stopped and discarded, and the result of the completed
+// iterations is returned with the error of the context.
+func Run(ctx context.Context, cfg Config) (*Result, error) {
+
+	c, err := cfg.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if c.SetMaxProcs {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(c.Threads))
+	}
+
+	res := &Result{Threads: c.Threads, Workers: c.Workers}
+	for i := 0; i < c.Iterations && err == nil; i++ {
+		var it *Iteration
+		if it, err = runIteration(ctx, c); err == nil {
+			res.Iterations = append(res.Iterations, it)
+		}
+	}
+	res.Stat = ComputeStat(res.throughputs())
+	res.Throughput = res.Stat.Max
+	return res, err
+}
+
+// RunIteration runs a single benchmark iteration. When the context is
+// cancelled, the iteration is stopped and returned with the error of the
+// context: it is incomplete.
+func RunIteration(ctx context.Context, cfg Config) (*Iteration, error) {
+
+	c, err := cfg.normalize()
+	if err != nil {
+		return nil, err
+	}
+	if c.SetMaxProcs {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(c.Threads))
+	}
+	return runIteration(ctx, c)
+}
+
+// normalize checks the configuration and returns a copy with the default values applied
+func (cfg Config) normalize() (*Config, error) {
+	if cfg.Workloads == nil {
+		return nil, ErrNoWorkloads
+	}
+	if err := checkTypes(cfg.Transactions); err != nil {
+		return nil, err
+	}
+	if cfg.Threads <= 0 {
+		cfg.Threads = runtime.GOMAXPROCS(0)
+	}
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4 * cfg.Threads
+	}
+	if cfg.Duration <= 0 {
+		cfg.Duration = DefaultDuration
+	}
+	if cfg.Iterations <= 0 {
+		cfg.Iterations = 1
+	}
+	if cfg.SteadyCoV <= 0.0 {
+		cfg.SteadyCoV = DefaultSteadyCoV
+	}
+	if cfg.Injector == nil {
+		cfg.Injector = InjectSaturation
+	}
+	return &cfg, nil
+}
+
+// logf logs a progress message if a logger is defined
+func (cfg *Config) logf(format string, v ...any) {
+	if cfg.Logger != nil {
+		cfg.Logger.Printf(format, v...)
+	}
+}
+
+// throughputs returns the throughput of each iteration
+func (r *Result) throughputs() []float64 {
+	res := make([]float64, len(r.Iterations))
+	for i, it := range r.Iterations {
+		res[i] = it.Throughput
+	}
+	return res
+}
+
+// runIteration runs a benchmark iteration with a normalized configuration
+func runIteration(ctx context.Context, cfg *Config) (*Iteration, error) {
+
+	// We will maintain the workers busy by pre-filling a buffered channel
+	init := make(chan WorkerOp, cfg.Workers)
+	input := make(chan WorkerMsg, cfg.Workers*32)
+	output := make(chan workerResult, cfg.Workers)
+	progress := &progress{}
+
+	cfg.logf("Initializing workers")
+
+	// Spawn workers and trigger initialization
+	workers := []*worker{}
+	for i := 0; i < cfg.Workers; i++ {
+		w := newWorker(i, cfg, init, input, output, progress)
+		workers = append(workers, w)
+		go w.Run()
+		init <- OpInit
+	}
+
+	// Wait for all workers to be initialized. If a worker cannot be
+	// initialized, all the workers are stopped.
+	var err error
+	for range workers {
+		if r := <-output; r.err != nil {
+			err = r.err
+		}
+	}
+	if err != nil {
+		for range workers {
+			input <- WorkerMsg{Op: OpExit}
+		}
+		for range workers {
+			<-output
+		}
+		return nil, err
+	}
+
+	// Run a synchronous garbage collection now to avoid processing the garbage
+	// associated to the initialization during the benchmark
+	runtime.GC()
+	runtime.GC()
+
+	// Start the benchmark: after the warm-up phase, the measurement runs for a given duration
+	cfg.logf("Start")
+	stop := make(chan bool)
+	started := make(chan time.Time, 1)
+	var warmup time.Duration
+	sampler := newTimelineSampler(progress)
+	go func() {
+		warmup = warmUp(ctx, cfg, progress, steadyInterval)
+		progress.Measuring.Store(true)
+		sampler.Start()
+		started <- time.Now()
+		if sleepContext(ctx, cfg.Duration) {
+			cfg.logf("Stop signal")
+		}
+		stop <- true
+	}()
+
+	// Apply the injection
+	cfg.Injector(cfg, input, stop)
+	begin := <-started
+
+	// Signal the end of the benchmark to workers, and aggregate results
+	for range workers {
+		input <- WorkerMsg{Op: OpExit}
+	}
+	nb := 0
+	elapsed := make([]time.Duration, len(workers[0].workloads))
+	typeNb := make([]int, len(workers[0].types))
+	typeElapsed := make([]time.Duration, len(workers[0].types))
+	queue, service, total := NewHistogram(), NewHistogram(), NewHistogram()
+	for range workers {
+		r := <-output
+		nb += r.nb
+		for i, d := range r.elapsed {
+			elapsed[i] += d
+		}
+		for i, n := range r.typeNb {
+			typeNb[i] += n
+			typeElapsed[i] += r.typeElapsed[i]
+		}
+		queue.Merge(r.queue)
+		service.Merge(r.service)
+		total.Merge(r.total)
+	}
+	end := time.Now()
+	samples := sampler.Stop()
+	cfg.logf("End")
+
+	// Calculate resulting throughput, and the costs of the workloads and types
+	ns := float64(end.Sub(begin).Nanoseconds())
+	share := cpuShare(elapsed, cfg.Threads, end.Sub(begin))
+	res := &Iteration{
+		Start:        begin,
+		End:          end,
+		Warmup:       warmup,
+		Transactions: nb,
+		Throughput:   float64(nb) * 1000000000.0 / ns,
+		Breakdown:    newBreakdown(workers[0].workloads, elapsed, nb, share),
+		Latency:      &Latency{Queue: queue.Stat(), Service: service.Stat(), Total: total.Stat()},
+		Timeline:     NewTimeline(samples, timelineInterval, cfg.TimelineDrop),
+		Types:        newTypeStats(cfg.Transactions, typeNb, typeElapsed, end.Sub(begin), share),
+	}
+	return res, ctx.Err()
+}
+
+// sleepContext waits for a duration, and returns false if the context has been cancelled
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
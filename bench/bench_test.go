@@ -0,0 +1,106 @@
+package bench
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// spin is a trivial workload
+type spin struct{ n int }
+
+func (s *spin) Run() {
+	for i := 0; i < 1000; i++ {
+		s.n += i
+	}
+}
+
+func testConfig() Config {
+	return Config{
+		Workers:    2,
+		Duration:   200 * time.Millisecond,
+		Iterations: 2,
+		Workloads: func() []Workload {
+			return []Workload{{Name: "spin", Bench: &spin{}}}
+		},
+	}
+}
+
+func TestRun(t *testing.T) {
+	res, err := Run(context.Background(), testConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Workers != 2 || len(res.Iterations) != 2 || res.Stat.N != 2 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	for _, it := range res.Iterations {
+		if it.Transactions == 0 || it.Throughput <= 0.0 || len(it.Breakdown) != 1 || it.Breakdown[0].Name != "spin" {
+			t.Errorf("unexpected iteration: %+v", it)
+		}
+	}
+	if res.Throughput != max(res.Iterations[0].Throughput, res.Iterations[1].Throughput) {
+		t.Errorf("throughput=%v, expected the maximum of the iterations", res.Throughput)
+	}
+}
+
+func TestRunCancel(t *testing.T) {
+	cfg := testConfig()
+	cfg.Duration = time.Hour
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	res, err := Run(ctx, cfg)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err=%v, expected the error of the context", err)
+	}
+	if len(res.Iterations) != 0 {
+		t.Errorf("the interrupted iteration should be discarded")
+	}
+}
+
+func TestRunNoWorkloads(t *testing.T) {
+	if _, err := Run(context.Background(), Config{}); !errors.Is(err, ErrNoWorkloads) {
+		t.Errorf("err=%v, expected ErrNoWorkloads", err)
+	}
+}
+
+func TestRunMaxProcs(t *testing.T) {
+	procs := runtime.GOMAXPROCS(0)
+	cfg := testConfig()
+	cfg.Threads, cfg.Iterations, cfg.Duration = procs+1, 1, 50*time.Millisecond
+	cfg.Workloads = func() []Workload {
+		return []Workload{{Name: "procs", Bench: &probe{procs: &observed}}}
+	}
+
+	// GOMAXPROCS is only changed on request, and restored afterwards
+	for _, set := range []bool{false, true} {
+		cfg.SetMaxProcs = set
+		observed.Store(0)
+		if _, err := Run(context.Background(), cfg); err != nil {
+			t.Fatal(err)
+		}
+		expected := procs
+		if set {
+			expected = procs + 1
+		}
+		if n := int(observed.Load()); n != expected {
+			t.Errorf("SetMaxProcs=%v: GOMAXPROCS=%d during the benchmark, expected %d", set, n, expected)
+		}
+		if n := runtime.GOMAXPROCS(0); n != procs {
+			t.Errorf("SetMaxProcs=%v: GOMAXPROCS=%d after the benchmark, expected %d", set, n, procs)
+		}
+	}
+}
+
+// observed is the GOMAXPROCS value seen by the probe workload
+var observed atomic.Int32
+
+// probe is a workload recording the GOMAXPROCS value
+type probe struct{ procs *atomic.Int32 }
+
+func (p *probe) Run() {
+	p.procs.Store(int32(runtime.GOMAXPROCS(0)))
+}
@@ -0,0 +1,40 @@
+package bench
+
+import "time"
+
+// WorkloadCost is the average execution time of an algorithm per transaction (in ns),
+// excluding the time spent by the workers waiting for a CPU
+type WorkloadCost struct {
+	Name string  `json:"name"`
+	Cost float64 `json:"cost"`
+}
+
+// newBreakdown calculates the cost per transaction of each algorithm from the
+// time accumulated by all the workers, scaled by their CPU share (see cpuShare)
+func newBreakdown(workloads []Workload, elapsed []time.Duration, nb int, share float64) []WorkloadCost {
+	if nb == 0 {
+		return nil
+	}
+	res := make([]WorkloadCost, len(workloads))
+	for i, w := range workloads {
+		res[i] = WorkloadCost{Name: w.Name, Cost: share * float64(elapsed[i].Nanoseconds()) / float64(nb)}
+	}
+	return res
+}
+
+// cpuShare estimates the share of the time measured by the workers which is
+// actually spent on a CPU. With more workers than threads, the workers also
+// measure the time they wait for a CPU, so their accumulated time exceeds the
+// CPU time available during the iteration (threads * d): it is scaled down to
+// this capacity, so that the costs are comparable with a single thread.
+func cpuShare(elapsed []time.Duration, threads int, d time.Duration) float64 {
+	busy := 0.0
+	for _, e := range elapsed {
+		busy += float64(e.Nanoseconds())
+	}
+	capacity := float64(threads) * float64(d.Nanoseconds())
+	if busy <= capacity {
+		return 1.0
+	}
+	return capacity / busy
+}
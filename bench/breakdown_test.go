@@ -0,0 +1,47 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBreakdownShare(t *testing.T) {
+	workloads := []Workload{{Name: "a"}, {Name: "b"}}
+
+	// 4 saturated workers on 2 threads during 1s: half of their time is spent waiting
+	elapsed := []time.Duration{3 * time.Second, 1 * time.Second}
+	share := cpuShare(elapsed, 2, time.Second)
+	if share != 0.5 {
+		t.Fatalf("share=%f, expected 0.5", share)
+	}
+	b := newBreakdown(workloads, elapsed, 1000, share)
+	if b[0].Cost != 1.5e6 || b[1].Cost != 0.5e6 {
+		t.Errorf("unexpected breakdown: %+v", b)
+	}
+
+	// Idle workers do not wait for a CPU
+	if share := cpuShare(elapsed, 8, time.Second); share != 1.0 {
+		t.Errorf("share=%f, expected 1", share)
+	}
+	if b := newBreakdown(workloads, elapsed, 0, 1.0); b != nil {
+		t.Errorf("unexpected breakdown without transactions: %+v", b)
+	}
+}
+
+func TestBreakdownWorkers(t *testing.T) {
+	cfg := testConfig()
+	cfg.Threads, cfg.Workers, cfg.Iterations, cfg.SetMaxProcs = 1, 4, 1, true
+	res, err := Run(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The CPU time of the transactions cannot exceed the capacity of a single thread
+	it := res.Iterations[0]
+	used := it.Breakdown[0].Cost * it.Throughput / 1.0e9
+	if used > 1.05 || math.IsNaN(used) {
+		t.Errorf("breakdown uses %.2f threads, expected at most 1", used)
+	}
+}
@@ -0,0 +1,163 @@
+package bench
+
+import (
+	"math"
+	"math/bits"
+	"time"
+)
+
+// Layout of the histogram buckets. Values below histogramSub are recorded
+// exactly. Above, each power of two is split in histogramSub/2 buckets, so the
+// relative error is bounded by 2/histogramSub (about 1.6%). Values above
+// 2^histogramMaxBits ns (about 18 minutes) are recorded in the last bucket.
+const (
+	histogramSubBits = 7
+	histogramSub     = 1 << histogramSubBits
+	histogramHalf    = histogramSub / 2
+	histogramMaxBits = 40
+	histogramBuckets = histogramSub + (histogramMaxBits-histogramSubBits+1)*histogramHalf
+)
+
+// Histogram is a log-linear histogram of durations, similar to HDR histograms.
+// It is not thread-safe: each worker records in its own histograms, which are
+// merged by the driver.
+type Histogram struct {
+	counts []uint64
+	count  uint64
+	sum    float64
+	min    int64
+	max    int64
+}
+
+// NewHistogram allocates an empty histogram
+func NewHistogram() *Histogram {
+	return &Histogram{
+		counts: make([]uint64, histogramBuckets),
+		min:    math.MaxInt64,
+	}
+}
+
+// histogramIndex returns the bucket of a value (in ns)
+func histogramIndex(v int64) int {
+	if v < histogramSub {
+		return int(v)
+	}
+	k := bits.Len64(uint64(v)) - 1
+	if k > histogramMaxBits {
+		return histogramBuckets - 1
+	}
+	shift := k - histogramSubBits + 1
+	return histogramSub + (k-histogramSubBits)*histogramHalf + int(v>>shift) - histogramHalf
+}
+
+// histogramValue returns the middle value of a bucket (in ns)
+func histogramValue(i int) int64 {
+	if i < histogramSub {
+		return int64(i)
+	}
+	k := (i-histogramSub)/histogramHalf + histogramSubBits
+	shift := k - histogramSubBits + 1
+	m := int64((i-histogramSub)%histogramHalf + histogramHalf)
+	return m<<shift + (int64(1)<<shift)/2
+}
+
+// Record adds a duration to the histogram
+func (h *Histogram) Record(d time.Duration) {
+	v := int64(d)
+	if v < 0 {
+		v = 0
+	}
+	h.counts[histogramIndex(v)]++
+	h.count++
+	h.sum += float64(v)
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+}
+
+// Merge adds the content of another histogram
+func (h *Histogram) Merge(o *Histogram) {
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.count += o.count
+	h.sum += o.sum
+	h.min = min(h.min, o.min)
+	h.max = max(h.max, o.max)
+}
+
+// Count returns the number of recorded values
+func (h *Histogram) Count() uint64 {
+	return h.count
+}
+
+// Mean returns the average recorded duration
+func (h *Histogram) Mean() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / float64(h.count))
+}
+
+// Max returns the maximum recorded duration
+func (h *Histogram) Max() time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	return time.Duration(h.max)
+}
+
+// Quantile returns the q quantile (0<=q<=1) of the recorded durations
+func (h *Histogram) Quantile(q float64) time.Duration {
+	if h.count == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(h.count)))
+	if rank == 0 {
+		rank = 1
+	}
+	n := uint64(0)
+	for i, c := range h.counts {
+		n += c
+		if n >= rank {
+			v := max(min(histogramValue(i), h.max), h.min)
+			return time.Duration(v)
+		}
+	}
+	return time.Duration(h.max)
+}
+
+// LatencyStat summarizes a latency histogram (values in ms)
+type LatencyStat struct {
+	Count uint64  `json:"count"`
+	Mean  float64 `json:"mean"`
+	P50   float64 `json:"p50"`
+	P90   float64 `json:"p90"`
+	P99   float64 `json:"p99"`
+	P999  float64 `json:"p999"`
+	Max   float64 `json:"max"`
+}
+
+// Stat summarizes the histogram
+func (h *Histogram) Stat() LatencyStat {
+	ms := func(d time.Duration) float64 { return float64(d.Nanoseconds()) / 1.0e6 }
+	return LatencyStat{
+		Count: h.count,
+		Mean:  ms(h.Mean()),
+		P50:   ms(h.Quantile(0.50)),
+		P90:   ms(h.Quantile(0.90)),
+		P99:   ms(h.Quantile(0.99)),
+		P999:  ms(h.Quantile(0.999)),
+		Max:   ms(h.Max()),
+	}
+}
+
+// Latency contains the latency statistics of the transactions of an iteration.
+// The queue time is the time spent in the input channel, the service time
+// is the execution time of the transaction, and the total time is measured
+// from the intended start time of the transaction (corrected for coordinated
+// omission) or from the enqueue time.
+type Latency struct {
+	Queue   LatencyStat `json:"queue"`
+	Service LatencyStat `json:"service"`
+	Total   LatencyStat `json:"total"`
+}
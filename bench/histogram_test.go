@@ -0,0 +1,59 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestHistogramIndex(t *testing.T) {
+
+	// Buckets must be contiguous and ordered
+	prev := -1
+	for _, v := range []int64{0, 1, 127, 128, 129, 255, 256, 1000, 1 << 20, 1<<40 - 1, 1 << 40, 1 << 50} {
+		i := histogramIndex(v)
+		if i < prev || i >= histogramBuckets {
+			t.Errorf("histogramIndex(%d) = %d, previous %d", v, i, prev)
+		}
+		prev = i
+	}
+
+	// The middle value of the bucket is close to the recorded value
+	for _, v := range []int64{5, 200, 12345, 987654321} {
+		m := histogramValue(histogramIndex(v))
+		if e := math.Abs(float64(m-v)) / float64(v); e > 2.0/histogramSub {
+			t.Errorf("histogramValue(histogramIndex(%d)) = %d", v, m)
+		}
+	}
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	h1, h2 := NewHistogram(), NewHistogram()
+	for i := 1; i <= 500; i++ {
+		h1.Record(time.Duration(i) * time.Millisecond)
+		h2.Record(time.Duration(500+i) * time.Millisecond)
+	}
+	h1.Merge(h2)
+
+	if h1.Count() != 1000 || h1.Max() != time.Second {
+		t.Errorf("count=%d max=%v", h1.Count(), h1.Max())
+	}
+	tests := []struct {
+		q        float64
+		expected time.Duration
+	}{
+		{0.5, 500 * time.Millisecond},
+		{0.9, 900 * time.Millisecond},
+		{0.99, 990 * time.Millisecond},
+		{1.0, time.Second},
+	}
+	for _, tt := range tests {
+		res := h1.Quantile(tt.q)
+		if e := math.Abs(float64(res-tt.expected)) / float64(tt.expected); e > 2.0/histogramSub {
+			t.Errorf("Quantile(%v) = %v, expected %v", tt.q, res, tt.expected)
+		}
+	}
+	if m := h1.Mean(); m < 500*time.Millisecond || m > 501*time.Millisecond {
+		t.Errorf("Mean() = %v", m)
+	}
+}
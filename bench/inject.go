@@ -0,0 +1,152 @@
+package bench
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Injector is an injection policy: it sends the transactions to the workers
+// through the input channel until the stop channel is signaled
+type Injector func(cfg *Config, input chan WorkerMsg, stop chan bool)
+
+// InjectSaturation injects traffic by saturating the input queue.
+// It is used for the standard benchmark.
+func InjectSaturation(cfg *Config, input chan WorkerMsg, stop chan bool) {
+
+	// Saturation benchmark loop: we avoid checking for the timeout too often
+	for {
+		select {
+		case <-stop:
+			return
+		default:
+			for i := 0; i < cfg.Workers*16; i++ {
+				input <- WorkerMsg{Op: OpStep, Enqueued: time.Now()}
+			}
+		}
+	}
+}
+
+// InjectTicker injects traffic by limiting the input throughput to TPS,
+// with periodic bursts of transactions. It is used for the OLTP benchmark.
+func InjectTicker(cfg *Config, input chan WorkerMsg, stop chan bool) {
+
+	// Calculate a suitable period and number of transactions per period
+	var period int
+	switch {
+	case cfg.TPS < 10:
+		period = 1000
+	case cfg.TPS < 100:
+		period = 100
+	case cfg.TPS < 500:
+		period = 50
+	case cfg.TPS < 1000:
+		period = 20
+	default:
+		period = 10
+	}
+	nbPeriods := 1000 / period
+	nbTrans := cfg.TPS / nbPeriods
+
+	// Rounding errors need to be corrected, so the first period is adjusted with a bit more transactions
+	nbTransFirst := cfg.TPS - nbTrans*nbPeriods
+	if nbTransFirst < 0 {
+		nbTransFirst = 0
+	}
+
+	cfg.logf("Injection: %d transactions every %d ms for %d periods/s", nbTrans, period, nbPeriods)
+	cfg.logf("Injection correction: %d", nbTransFirst)
+	ticker := time.Tick(time.Duration(period) * time.Millisecond)
+	iPeriod := 0
+
+	// Inject nbTrans transactions for each period
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker:
+			n := nbTrans
+			if iPeriod == 0 {
+				n += nbTransFirst
+			}
+			for i := 0; i < n; i++ {
+				input <- WorkerMsg{Op: OpStep, Enqueued: time.Now()}
+			}
+			iPeriod++
+			if iPeriod == nbPeriods {
+				iPeriod = 0
+			}
+		}
+	}
+}
+
+// InjectPoisson injects traffic in open loop: transactions arrive one by one with
+// exponential inter-arrival times (i.e. a Poisson process) at the target throughput.
+// The schedule of the intended start times never depends on the sending of the
+// transactions: if the injector is delayed (because the queue is full), the late
+// transactions are sent as soon as possible, and they carry their intended start
+// time, so the latency is corrected for coordinated omission.
+func InjectPoisson(cfg *Config, input chan WorkerMsg, stop chan bool) {
+
+	if cfg.TPS <= 0 {
+		<-stop
+		return
+	}
+
+	arr := newArrivals(cfg.TPS, time.Now())
+	cfg.logf("Injection: Poisson arrivals, mean interval %.3f ms", arr.mean/1.0e6)
+
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	late, maxLag := 0, time.Duration(0)
+
+	for {
+		// Wait for the intended start time of the next transaction
+		next := arr.Next()
+		if wait := time.Until(next); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-stop:
+				cfg.logf("Injection: %d transactions sent late, maximum lag %v", late, maxLag)
+				return
+			case <-timer.C:
+			}
+		}
+
+		// Send it, even if it is late
+		now := time.Now()
+		if lag := now.Sub(next); lag > time.Millisecond {
+			late++
+			maxLag = max(maxLag, lag)
+		}
+		select {
+		case <-stop:
+			cfg.logf("Injection: %d transactions sent late, maximum lag %v", late, maxLag)
+			return
+		case input <- WorkerMsg{Op: OpStep, Enqueued: now, Intended: next}:
+		}
+	}
+}
+
+// arrivals is the schedule of the intended start times of a Poisson process
+type arrivals struct {
+	rnd  *rand.Rand
+	mean float64
+	next time.Time
+}
+
+// newArrivals creates the schedule of the arrivals at a given throughput,
+// from a start time. The random generator is seeded with a constant, so the
+// arrivals are reproducible.
+func newArrivals(tps int, start time.Time) *arrivals {
+	return &arrivals{
+		rnd:  rand.New(rand.NewPCG(1, uint64(tps))),
+		mean: float64(time.Second) / float64(tps),
+		next: start,
+	}
+}
+
+// Next returns the intended start time of the next transaction
+func (a *arrivals) Next() time.Time {
+	a.next = a.next.Add(time.Duration(a.rnd.ExpFloat64() * a.mean))
+	return a.next
+}
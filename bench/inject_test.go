@@ -0,0 +1,88 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestArrivals(t *testing.T) {
+
+	// The mean inter-arrival time is the inverse of the throughput
+	start := time.Now()
+	a, b := newArrivals(1000, start), newArrivals(1000, start)
+	n := 100000
+	var last time.Time
+	for range n {
+		last = a.Next()
+		if b.Next() != last {
+			t.Fatal("the arrivals should be reproducible")
+		}
+	}
+	mean := last.Sub(start).Seconds() / float64(n)
+	if math.Abs(mean-0.001) > 0.00002 {
+		t.Errorf("mean interval %.6f s, expected 0.001 s", mean)
+	}
+}
+
+func TestInjectPoissonStall(t *testing.T) {
+
+	// The worker is stalled: nothing is read from the input channel for a while
+	cfg := &Config{TPS: 1000}
+	input, stop := make(chan WorkerMsg), make(chan bool)
+	done := make(chan bool)
+	go func() {
+		InjectPoisson(cfg, input, stop)
+		done <- true
+	}()
+	time.Sleep(200 * time.Millisecond)
+	msgs := make([]WorkerMsg, 100)
+	for i := range msgs {
+		msgs[i] = <-input
+	}
+	close(stop)
+	<-done
+
+	// The intended start times follow the schedule, whatever the sending delay
+	a := newArrivals(cfg.TPS, time.Time{})
+	first := a.Next()
+	for i, msg := range msgs {
+		if i > 0 && msg.Intended.Sub(msgs[0].Intended) != a.Next().Sub(first) {
+			t.Fatalf("transaction %d: intended start time off schedule", i)
+		}
+		if msg.Intended.After(msg.Enqueued) {
+			t.Errorf("transaction %d sent before its intended start time", i)
+		}
+	}
+
+	// The transactions scheduled during the stall are sent late
+	for i, msg := range msgs[1:50] {
+		if lag := msg.Enqueued.Sub(msg.Intended); lag < 100*time.Millisecond {
+			t.Errorf("transaction %d: lag %v, expected the duration of the stall", i+1, lag)
+		}
+	}
+}
+
+func TestWorkerIntendedStart(t *testing.T) {
+
+	// A transaction intended to start 50 ms before being enqueued
+	cfg := &Config{Workloads: func() []Workload { return []Workload{{Name: "spin", Bench: &spin{}}} }}
+	init, input, output := make(chan WorkerOp, 1), make(chan WorkerMsg, 2), make(chan workerResult, 1)
+	p := &progress{}
+	p.Measuring.Store(true)
+	w := newWorker(0, cfg, init, input, output, p)
+	go w.Run()
+	init <- OpInit
+	if r := <-output; r.err != nil {
+		t.Fatal(r.err)
+	}
+	now := time.Now()
+	input <- WorkerMsg{Op: OpStep, Enqueued: now, Intended: now.Add(-50 * time.Millisecond)}
+	input <- WorkerMsg{Op: OpExit}
+	r := <-output
+
+	// The total latency is measured from the intended start time, not the queue time
+	if r.nb != 1 || r.total.Max() < 50*time.Millisecond || r.queue.Max() >= 50*time.Millisecond {
+		t.Errorf("unexpected latency: total %v, queue %v", r.total.Max(), r.queue.Max())
+	}
+}
@@ -0,0 +1,187 @@
+package bench
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// medianLevel is the targeted confidence level of the median confidence interval
+const medianLevel = 0.95
+
+// Stat contains the statistics calculated on a series of results
+type Stat struct {
+	N          int     `json:"n"`
+	Min        float64 `json:"min"`
+	Average    float64 `json:"average"`
+	Median     float64 `json:"median"`
+	GeoMean    float64 `json:"geo_mean"`
+	Max        float64 `json:"max"`
+	StdDev     float64 `json:"std_dev"`
+	CoV        float64 `json:"cov"`
+	MedianLow  float64 `json:"median_low"`
+	MedianHigh float64 `json:"median_high"`
+	MedianConf float64 `json:"median_conf"`
+	Outliers   []int   `json:"outliers,omitempty"`
+}
+
+// MarshalJSON encodes the statistics. The geometric mean is not defined when a
+// result is zero (e.g. an interrupted iteration): since JSON cannot represent
+// NaN, it is encoded as null.
+func (s Stat) MarshalJSON() ([]byte, error) {
+	type stat Stat
+	x := struct {
+		stat
+		GeoMean *float64 `json:"geo_mean"`
+	}{stat: stat(s)}
+	if !math.IsNaN(s.GeoMean) && !math.IsInf(s.GeoMean, 0) {
+		x.GeoMean = &s.GeoMean
+	}
+	return json.Marshal(x)
+}
+
+// ComputeStat calculates basic statistics on a series of results.
+// The series itself is not modified.
+func ComputeStat(r []float64) Stat {
+
+	if len(r) == 0 {
+		return Stat{}
+	}
+
+	// Calculate min, max, and median from sorted results
+	sorted := sortedCopy(r)
+
+	// Calculate average and geo man
+	s := Stat{
+		N:       len(sorted),
+		Min:     sorted[0],
+		Average: Average(sorted),
+		Median:  medianSorted(sorted),
+		GeoMean: geoMean(sorted),
+		Max:     sorted[len(sorted)-1],
+	}
+
+	// Calculate dispersion
+	s.StdDev = StdDev(sorted)
+	if s.Average != 0.0 {
+		s.CoV = 100.0 * s.StdDev / s.Average
+	}
+	s.MedianLow, s.MedianHigh, s.MedianConf = medianCI(sorted, medianLevel)
+
+	// Flag the iterations outside of the Tukey fences
+	q1, q3 := Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+	low, high := q1-1.5*(q3-q1), q3+1.5*(q3-q1)
+	for i, x := range r {
+		if x < low || x > high {
+			s.Outliers = append(s.Outliers, i)
+		}
+	}
+	return s
+}
+
+// sortedCopy returns a sorted copy of a series of results
+func sortedCopy(r []float64) []float64 {
+	res := make([]float64, len(r))
+	copy(res, r)
+	sort.Float64s(res)
+	return res
+}
+
+// medianSorted calculates the median of sorted results
+func medianSorted(r []float64) float64 {
+	if len(r) == 0 {
+		return math.NaN()
+	}
+	if len(r)%2 == 0 {
+		a, b := r[len(r)/2-1], r[len(r)/2]
+		return (a + b) / 2.0
+	}
+	return r[len(r)/2]
+}
+
+// Median calculates the median of a series of results. The series itself is not modified.
+func Median(r []float64) float64 {
+	return medianSorted(sortedCopy(r))
+}
+
+// Quantile calculates the q quantile (0<=q<=1) of sorted results by linear interpolation
+func Quantile(r []float64, q float64) float64 {
+	if len(r) == 0 {
+		return math.NaN()
+	}
+	pos := q * float64(len(r)-1)
+	i := int(math.Floor(pos))
+	if i >= len(r)-1 {
+		return r[len(r)-1]
+	}
+	return r[i] + (pos-float64(i))*(r[i+1]-r[i])
+}
+
+// StdDev calculates the sample standard deviation
+func StdDev(r []float64) float64 {
+	if len(r) < 2 {
+		return 0.0
+	}
+	avg := Average(r)
+	sum := 0.0
+	for _, x := range r {
+		sum += (x - avg) * (x - avg)
+	}
+	return math.Sqrt(sum / float64(len(r)-1))
+}
+
+// medianCI calculates a distribution-free confidence interval of the median from
+// sorted results, using order statistics. It returns the bounds and the actual
+// confidence level, which is higher than the requested one, except for very small
+// series where the interval is the whole range.
+func medianCI(r []float64, level float64) (float64, float64, float64) {
+	n := len(r)
+	if n == 0 {
+		return math.NaN(), math.NaN(), 0.0
+	}
+
+	// Find the largest k such as P(B < k) <= alpha/2 with B following Binomial(n, 0.5)
+	alpha := 1.0 - level
+	k := 1
+	for k < (n+1)/2 && binomialCDF(n, k) <= alpha/2.0 {
+		k++
+	}
+	return r[k-1], r[n-k], 1.0 - 2.0*binomialCDF(n, k-1)
+}
+
+// binomialCDF calculates P(B <= k) with B following Binomial(n, 0.5)
+func binomialCDF(n, k int) float64 {
+	sum, c := 0.0, 1.0
+	for i := 0; i <= k && i <= n; i++ {
+		sum += c
+		c = c * float64(n-i) / float64(i+1)
+	}
+	return sum * math.Pow(0.5, float64(n))
+}
+
+// Average calculates the arithmetic mean
+func Average(r []float64) float64 {
+	if len(r) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, x := range r {
+		sum += x
+	}
+	return sum / float64(len(r))
+}
+
+// geoMean calculates the geometric mean
+func geoMean(r []float64) float64 {
+	if len(r) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, x := range r {
+		if x <= 0.0 {
+			return math.NaN()
+		}
+		sum += math.Log(x)
+	}
+	return math.Exp(sum / float64(len(r)))
+}
@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestQuantile(t *testing.T) {
+	r := []float64{1, 2, 3, 4, 5}
+	tests := []struct {
+		q, expected float64
+	}{
+		{0.0, 1.0},
+		{0.5, 3.0},
+		{1.0, 5.0},
+		{0.125, 1.5},
+	}
+	for _, tt := range tests {
+		if res := Quantile(r, tt.q); res != tt.expected {
+			t.Errorf("quantile(%v) = %v, expected %v", tt.q, res, tt.expected)
+		}
+	}
+	if m := Median([]float64{4, 1, 3, 2}); m != 2.5 {
+		t.Errorf("median = %v, expected 2.5", m)
+	}
+}
+
+func TestComputeStat(t *testing.T) {
+	r := []float64{100, 101, 99, 100, 102, 98, 100, 101, 70, 100}
+	s := ComputeStat(r)
+	if s.N != 10 || s.Min != 70 || s.Max != 102 || s.Median != 100 {
+		t.Errorf("unexpected statistics: %+v", s)
+	}
+	if len(s.Outliers) != 1 || s.Outliers[0] != 8 {
+		t.Errorf("outliers=%v, expected [8]", s.Outliers)
+	}
+	if math.Abs(s.StdDev-9.5853) > 0.001 || math.Abs(s.CoV-100.0*s.StdDev/s.Average) > 1e-9 {
+		t.Errorf("std dev=%v cov=%v", s.StdDev, s.CoV)
+	}
+	if s.MedianLow != 98 || s.MedianHigh != 101 || math.Abs(s.MedianConf-0.9785) > 0.0001 {
+		t.Errorf("median CI [%v, %v] at %v", s.MedianLow, s.MedianHigh, s.MedianConf)
+	}
+	if r[8] != 70 {
+		t.Error("the series should not be modified")
+	}
+}
+
+func TestStatJSON(t *testing.T) {
+
+	// A zero result gives a NaN geometric mean, encoded as null
+	b, err := json.Marshal(ComputeStat([]float64{0.0, 4.0}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		t.Fatal(err)
+	}
+	if g, ok := m["geo_mean"]; !ok || g != nil || m["max"] != 4.0 || len(m) != 11 {
+		t.Errorf("unexpected document: %s", b)
+	}
+
+	// Otherwise, the statistics round-trip
+	s := ComputeStat([]float64{1.0, 4.0})
+	var x Stat
+	if b, err = json.Marshal(s); err == nil {
+		err = json.Unmarshal(b, &x)
+	}
+	if err != nil || x.GeoMean != 2.0 || x.N != 2 || x.Max != 4.0 {
+		t.Errorf("unexpected statistics: %+v (%v)", x, err)
+	}
+}
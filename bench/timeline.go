@@ -0,0 +1,90 @@
+package bench
+
+import "time"
+
+// timelineInterval is the sampling interval of the throughput timeline
+const timelineInterval = time.Second
+
+// Timeline is the throughput sampled at a fixed interval during the
+// measurement window of an iteration. Drops are the samples significantly
+// below the median throughput of the iteration (throttling, noisy neighbours).
+type Timeline struct {
+	Interval   float64        `json:"interval"`
+	Throughput []float64      `json:"throughput"`
+	Median     float64        `json:"median"`
+	Drops      []TimelineDrop `json:"drops,omitempty"`
+}
+
+// TimelineDrop is a sample of the timeline below the drop threshold
+type TimelineDrop struct {
+	Offset     float64 `json:"offset"`
+	Throughput float64 `json:"throughput"`
+	Drop       float64 `json:"drop"`
+}
+
+// timelineSampler periodically samples the number of transactions completed by the workers
+type timelineSampler struct {
+	progress *progress
+	done     chan struct{}
+	res      chan []float64
+}
+
+// newTimelineSampler creates a sampler of the progress of the workers
+func newTimelineSampler(p *progress) *timelineSampler {
+	return &timelineSampler{
+		progress: p,
+		done:     make(chan struct{}),
+		res:      make(chan []float64, 1),
+	}
+}
+
+// Start starts the sampling in the background
+func (s *timelineSampler) Start() {
+	go func() {
+		ticker := time.NewTicker(timelineInterval)
+		defer ticker.Stop()
+		var samples []float64
+		last, t0 := s.progress.Done.Load(), time.Now()
+		for {
+			select {
+			case t1 := <-ticker.C:
+				n := s.progress.Done.Load()
+				samples = append(samples, float64(n-last)/t1.Sub(t0).Seconds())
+				last, t0 = n, t1
+			case <-s.done:
+				s.res <- samples
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the sampling and returns the throughput of each complete interval
+func (s *timelineSampler) Stop() []float64 {
+	close(s.done)
+	return <-s.res
+}
+
+// NewTimeline builds the timeline from the samples, and detects the drops of
+// throughput beyond a threshold (in %) relative to the median
+func NewTimeline(samples []float64, interval time.Duration, threshold float64) *Timeline {
+	res := &Timeline{Interval: interval.Seconds(), Throughput: samples}
+	if len(samples) == 0 {
+		return res
+	}
+	res.Median = Median(samples)
+	if threshold <= 0.0 || res.Median <= 0.0 {
+		return res
+	}
+	for i, x := range samples {
+		drop := 100.0 * (res.Median - x) / res.Median
+		if drop > threshold {
+			res.Drops = append(res.Drops, TimelineDrop{
+				Offset:     float64(i+1) * res.Interval,
+				Throughput: x,
+				Drop:       drop,
+			})
+		}
+	}
+	return res
+}
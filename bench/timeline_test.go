@@ -0,0 +1,23 @@
+package bench
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestNewTimeline(t *testing.T) {
+	tl := NewTimeline([]float64{100, 102, 98, 60, 101, 99, 85}, time.Second, 10.0)
+	if tl.Median != 99 {
+		t.Errorf("median=%v, expected 99", tl.Median)
+	}
+	if len(tl.Drops) != 2 || tl.Drops[0].Offset != 4 || tl.Drops[1].Offset != 7 {
+		t.Fatalf("unexpected drops: %+v", tl.Drops)
+	}
+	if math.Abs(tl.Drops[0].Drop-100.0*39.0/99.0) > 1e-9 {
+		t.Errorf("drop=%v", tl.Drops[0].Drop)
+	}
+	if tl := NewTimeline([]float64{100, 60}, time.Second, 0.0); len(tl.Drops) != 0 {
+		t.Errorf("unexpected drops with detection disabled: %+v", tl.Drops)
+	}
+}
@@ -0,0 +1,114 @@
+package bench
+
+import (
+	"fmt"
+	"time"
+)
+
+// TransactionType is a named sequence of workloads. At each transaction, a
+// worker draws the type to run with a probability proportional to Probability.
+type TransactionType struct {
+	Name        string
+	Probability float64
+	Steps       []TransactionStep
+}
+
+// TransactionStep runs a workload (designated by its name) Repeat times (at least once)
+type TransactionStep struct {
+	Workload string
+	Repeat   int
+}
+
+// TypeStat is the throughput of a transaction type during an iteration, and its
+// average execution time per transaction (in ns), excluding the time spent by
+// the workers waiting for a CPU (see WorkloadCost)
+type TypeStat struct {
+	Name         string  `json:"name"`
+	Transactions int     `json:"transactions"`
+	Throughput   float64 `json:"throughput"`
+	Cost         float64 `json:"cost"`
+}
+
+// txType is a transaction type resolved against the workloads of a worker:
+// the indexes of the workloads run in sequence, and the cumulated probability
+// used to draw the type
+type txType struct {
+	runs  []int
+	cumul float64
+}
+
+// checkTypes validates the transaction types of the configuration
+func checkTypes(types []TransactionType) error {
+	for _, t := range types {
+		if t.Name == "" || len(t.Steps) == 0 {
+			return fmt.Errorf("bench: transaction type %q without name or steps", t.Name)
+		}
+		if t.Probability <= 0.0 {
+			return fmt.Errorf("bench: transaction type %q: the probability must be positive", t.Name)
+		}
+	}
+	return nil
+}
+
+// resolveTypes resolves the transaction types against the workloads. Without
+// transaction types, a single type runs all the workloads according to their weight.
+func resolveTypes(types []TransactionType, workloads []Workload) ([]txType, error) {
+
+	if len(types) == 0 {
+		t := txType{cumul: 1.0}
+		for i, x := range workloads {
+			for range max(x.Weight, 1) {
+				t.runs = append(t.runs, i)
+			}
+		}
+		return []txType{t}, nil
+	}
+
+	index := map[string]int{}
+	for i, x := range workloads {
+		index[x.Name] = i
+	}
+	res := make([]txType, len(types))
+	cumul := 0.0
+	for i, t := range types {
+		for _, s := range t.Steps {
+			k, ok := index[s.Workload]
+			if !ok {
+				return nil, fmt.Errorf("bench: transaction type %q: unknown workload %q", t.Name, s.Workload)
+			}
+			for range max(s.Repeat, 1) {
+				res[i].runs = append(res[i].runs, k)
+			}
+		}
+		cumul += t.Probability
+		res[i].cumul = cumul
+	}
+	return res, nil
+}
+
+// drawType returns the index of a transaction type, drawn from a uniform random number in [0,1)
+func drawType(types []txType, x float64) int {
+	x *= types[len(types)-1].cumul
+	for i, t := range types {
+		if x < t.cumul {
+			return i
+		}
+	}
+	return len(types) - 1
+}
+
+// newTypeStats calculates the throughput and the cost of each transaction type,
+// scaled by the CPU share of the workers
+func newTypeStats(types []TransactionType, nb []int, elapsed []time.Duration, d time.Duration, share float64) []TypeStat {
+	if len(types) == 0 {
+		return nil
+	}
+	res := make([]TypeStat, len(types))
+	for i, t := range types {
+		res[i] = TypeStat{Name: t.Name, Transactions: nb[i], Throughput: float64(nb[i]) * 1000000000.0 / float64(d.Nanoseconds())}
+		if nb[i] > 0 {
+			res[i].Cost = share * float64(elapsed[i].Nanoseconds()) / float64(nb[i])
+		}
+	}
+	return res
+}
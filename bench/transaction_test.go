@@ -0,0 +1,73 @@
+package bench
+
+import (
+	"context"
+	"math"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestResolveTypes(t *testing.T) {
+	workloads := []Workload{{Name: "a", Weight: 2}, {Name: "b"}}
+
+	// Without transaction types, all the workloads run according to their weight
+	res, err := resolveTypes(nil, workloads)
+	if err != nil || len(res) != 1 || !slices.Equal(res[0].runs, []int{0, 0, 1}) {
+		t.Errorf("unexpected implicit type: %+v, %v", res, err)
+	}
+
+	types := []TransactionType{
+		{Name: "x", Probability: 3, Steps: []TransactionStep{{Workload: "b", Repeat: 2}, {Workload: "a"}}},
+		{Name: "y", Probability: 1, Steps: []TransactionStep{{Workload: "a"}}},
+	}
+	res, err = resolveTypes(types, workloads)
+	if err != nil || len(res) != 2 || !slices.Equal(res[0].runs, []int{1, 1, 0}) || res[1].cumul != 4 {
+		t.Errorf("unexpected types: %+v, %v", res, err)
+	}
+	for _, tt := range []struct {
+		x        float64
+		expected int
+	}{{0.0, 0}, {0.74, 0}, {0.75, 1}, {0.99, 1}} {
+		if i := drawType(res, tt.x); i != tt.expected {
+			t.Errorf("drawType(%v) = %d, expected %d", tt.x, i, tt.expected)
+		}
+	}
+
+	types[1].Steps[0].Workload = "c"
+	if _, err := resolveTypes(types, workloads); err == nil {
+		t.Error("an unknown workload should be rejected")
+	}
+}
+
+func TestRunTypes(t *testing.T) {
+	cfg := testConfig()
+	cfg.Iterations = 1
+	cfg.Workers = 1
+	cfg.Seed = 42
+	cfg.Transactions = []TransactionType{
+		{Name: "light", Probability: 3, Steps: []TransactionStep{{Workload: "spin"}}},
+		{Name: "heavy", Probability: 1, Steps: []TransactionStep{{Workload: "spin", Repeat: 10}}},
+	}
+	it, err := RunIteration(context.Background(), cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(it.Types) != 2 || it.Types[0].Transactions+it.Types[1].Transactions != it.Transactions {
+		t.Fatalf("unexpected types: %+v", it.Types)
+	}
+	if ratio := float64(it.Types[0].Transactions) / float64(it.Transactions); math.Abs(ratio-0.75) > 0.05 {
+		t.Errorf("share of the light transactions = %v, expected 0.75", ratio)
+	}
+
+	cfg.Transactions[0].Probability = 0
+	if _, err := RunIteration(context.Background(), cfg); err == nil {
+		t.Error("a null probability should be rejected")
+	}
+	cfg.Transactions[0].Probability = 1
+	cfg.Transactions[0].Steps[0].Workload = "unknown"
+	cfg.Duration = time.Hour
+	if _, err := RunIteration(context.Background(), cfg); err == nil {
+		t.Error("an unknown workload should be rejected")
+	}
+}
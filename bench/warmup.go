@@ -0,0 +1,88 @@
+package bench
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// Parameters of the steady state detection: the throughput is sampled at
+// each interval, and the steady state is reached when the coefficient of
+// variation of the last samples is below the SteadyCoV threshold. Without
+// Warmup, the detection lasts at most a fraction of the duration of the
+// iteration (but long enough to fill the window of samples).
+const (
+	steadyInterval = time.Second
+	steadyWindow   = 5
+	steadyFraction = 4
+)
+
+// progress is shared by the workers and the driver of a benchmark iteration.
+// Workers publish the number of completed transactions, and only record their
+// statistics once the driver has started the measurement window.
+type progress struct {
+	Done      atomic.Int64
+	Measuring atomic.Bool
+}
+
+// warmUp runs the warm-up phase of an iteration, while the injector is already
+// running, and returns its duration. With Steady, the warm-up lasts until the
+// throughput sampled at each interval is stable, or at most the limit given
+// by warmUpLimit. It is cut short when the context is cancelled.
+func warmUp(ctx context.Context, cfg *Config, p *progress, interval time.Duration) time.Duration {
+
+	begin := time.Now()
+	if !cfg.Steady {
+		if cfg.Warmup > 0 && sleepContext(ctx, cfg.Warmup) {
+			cfg.logf("Warm-up: %s", time.Since(begin).Round(time.Millisecond))
+		}
+		return time.Since(begin)
+	}
+	limit := warmUpLimit(cfg, interval)
+
+	// Sample the throughput until the steady state is detected
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var samples []float64
+	last, t0 := p.Done.Load(), begin
+	for time.Since(begin) < limit {
+		var t1 time.Time
+		select {
+		case t1 = <-ticker.C:
+		case <-ctx.Done():
+			return time.Since(begin)
+		}
+		n := p.Done.Load()
+		samples = append(samples, float64(n-last)/t1.Sub(t0).Seconds())
+		last, t0 = n, t1
+		if isSteady(samples, cfg.SteadyCoV) {
+			cfg.logf("Warm-up: steady state reached after %s", time.Since(begin).Round(time.Millisecond))
+			return time.Since(begin)
+		}
+	}
+	cfg.logf("Warm-up: no steady state after %s", time.Since(begin).Round(time.Millisecond))
+	return time.Since(begin)
+}
+
+// warmUpLimit returns the maximum duration of the warm-up phase with Steady:
+// Warmup, or by default a fraction of the duration of the iteration
+func warmUpLimit(cfg *Config, interval time.Duration) time.Duration {
+	if cfg.Warmup > 0 {
+		return cfg.Warmup
+	}
+	return max(cfg.Duration/steadyFraction, steadyWindow*interval)
+}
+
+// isSteady returns true if the coefficient of variation (in %) of the last
+// samples of a throughput series is below the threshold
+func isSteady(samples []float64, threshold float64) bool {
+	if len(samples) < steadyWindow {
+		return false
+	}
+	w := samples[len(samples)-steadyWindow:]
+	avg := Average(w)
+	if avg <= 0.0 {
+		return false
+	}
+	return 100.0*StdDev(w)/avg <= threshold
+}
@@ -0,0 +1,85 @@
+package bench
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestIsSteady(t *testing.T) {
+	tests := []struct {
+		samples  []float64
+		expected bool
+	}{
+		{[]float64{100, 100, 100, 100}, false},
+		{[]float64{50, 80, 100, 101, 99, 100, 100}, true},
+		{[]float64{50, 80, 100, 101, 99, 100, 120}, false},
+		{[]float64{0, 0, 0, 0, 0}, false},
+	}
+	for _, tt := range tests {
+		if res := isSteady(tt.samples, 2.0); res != tt.expected {
+			t.Errorf("isSteady(%v) = %v, expected %v", tt.samples, res, tt.expected)
+		}
+	}
+}
+
+func TestWarmUpLimit(t *testing.T) {
+	tests := []struct {
+		warmup, duration, expected time.Duration
+	}{
+		{0, 60 * time.Second, 15 * time.Second},
+		{30 * time.Second, 60 * time.Second, 30 * time.Second},
+		{0, 8 * time.Second, 5 * time.Second},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Steady: true, Warmup: tt.warmup, Duration: tt.duration}
+		if res := warmUpLimit(cfg, time.Second); res != tt.expected {
+			t.Errorf("warmUpLimit(%v, %v) = %v, expected %v", tt.warmup, tt.duration, res, tt.expected)
+		}
+	}
+}
+
+func TestWarmUpSteady(t *testing.T) {
+
+	// The transactions complete at a constant pace
+	p := &progress{}
+	stop := make(chan bool)
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.Done.Add(10)
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	// The warm-up stops at the steady state, long before the limit
+	cfg := &Config{Steady: true, SteadyCoV: 25.0, Duration: time.Minute}
+	if d := warmUp(context.Background(), cfg, p, 20*time.Millisecond); d < 5*20*time.Millisecond || d > 5*time.Second {
+		t.Errorf("warm-up %v, expected the steady state", d)
+	}
+}
+
+func TestWarmUpNotSteady(t *testing.T) {
+
+	// No transaction completes: the warm-up stops at the limit
+	p := &progress{}
+	tests := []struct {
+		warmup, expected time.Duration
+	}{
+		{0, 200 * time.Millisecond},
+		{300 * time.Millisecond, 300 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		cfg := &Config{Steady: true, SteadyCoV: 5.0, Warmup: tt.warmup, Duration: 800 * time.Millisecond}
+		d := warmUp(context.Background(), cfg, p, 20*time.Millisecond)
+		if d < tt.expected || d > tt.expected+time.Second {
+			t.Errorf("warm-up %v, expected %v", d, tt.expected)
+		}
+	}
+}
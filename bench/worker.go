@@ -0,0 +1,183 @@
+package bench
+
+import (
+	"math/rand/v2"
+	"time"
+)
+
+// Benchmark is just a runnable thing. A transaction runs each workload once.
+type Benchmark interface {
+	Run()
+}
+
+// Workload is a named benchmark algorithm, run Weight times (at least once) at
+// each transaction
+type Workload struct {
+	Name   string
+	Bench  Benchmark
+	Weight int
+}
+
+// WorkerOp is an enumerate representing the type of operations processed by the workers
+type WorkerOp byte
+
+// Operations processed by the workers
+const (
+	OpNull WorkerOp = iota
+	OpInit
+	OpStep
+	OpExit
+)
+
+// WorkerMsg is an operation sent to the workers through the input channel.
+// Transactions carry the time they have been enqueued at, and optionally the
+// time they were intended to start at (when the injector can be late).
+type WorkerMsg struct {
+	Op       WorkerOp
+	Enqueued time.Time
+	Intended time.Time
+}
+
+// workerResult is sent by a worker to the driver after its initialization and at exit
+type workerResult struct {
+	err         error
+	nb          int
+	elapsed     []time.Duration
+	typeNb      []int
+	typeElapsed []time.Duration
+	queue       *Histogram
+	service     *Histogram
+	total       *Histogram
+}
+
+// worker does represent a single worker
+type worker struct {
+	id          int
+	cfg         *Config
+	init        chan WorkerOp
+	input       chan WorkerMsg
+	output      chan workerResult
+	progress    *progress
+	nb          int
+	workloads   []Workload
+	types       []txType
+	rnd         *rand.Rand
+	elapsed     []time.Duration
+	typeNb      []int
+	typeElapsed []time.Duration
+	queue       *Histogram
+	service     *Histogram
+	total       *Histogram
+}
+
+// newWorker creates a worker
+func newWorker(id int, cfg *Config, init chan WorkerOp, input chan WorkerMsg, output chan workerResult, progress *progress) *worker {
+	return &worker{
+		id:       id,
+		cfg:      cfg,
+		init:     init,
+		input:    input,
+		output:   output,
+		progress: progress,
+	}
+}
+
+// Run is triggered when the worker is started
+func (w *worker) Run() {
+
+	// Let the caller prepare the goroutine (e.g. pin it to CPUs)
+	if w.cfg.WorkerStart != nil {
+		w.cfg.WorkerStart(w.id)
+	}
+
+	// Initialize the worker
+	<-w.init
+	if err := w.Init(); err != nil {
+		w.output <- workerResult{err: err}
+	} else {
+		w.output <- workerResult{}
+	}
+
+	// Main worker loop, fetching operations from the input channel.
+	// The time spent in the queue and in the execution of each transaction is
+	// recorded, except during the warm-up phase. The transaction type is drawn
+	// even during the warm-up phase, so the sequence of types is reproducible.
+	for msg := range w.input {
+		switch msg.Op {
+		case OpStep:
+			measuring := w.progress.Measuring.Load()
+			t := 0
+			if len(w.types) > 1 {
+				t = drawType(w.types, w.rnd.Float64())
+			}
+			begin := time.Now()
+			w.Step(t, measuring)
+			end := time.Now()
+			w.progress.Done.Add(1)
+			if !measuring {
+				continue
+			}
+			start := msg.Intended
+			if start.IsZero() {
+				start = msg.Enqueued
+			}
+			w.queue.Record(begin.Sub(msg.Enqueued))
+			w.service.Record(end.Sub(begin))
+			w.total.Record(end.Sub(start))
+			w.typeNb[t]++
+			w.typeElapsed[t] += end.Sub(begin)
+			w.nb++
+		case OpExit:
+			w.Exit()
+			return
+		default:
+			w.cfg.logf("Wrong operation %d", msg.Op)
+		}
+	}
+}
+
+// Init performs worker initialization. The random generator drawing the
+// transaction types is seeded with the seed of the configuration and the
+// identifier of the worker.
+func (w *worker) Init() error {
+	w.workloads = w.cfg.Workloads()
+	types, err := resolveTypes(w.cfg.Transactions, w.workloads)
+	if err != nil {
+		return err
+	}
+	w.types = types
+	w.rnd = rand.New(rand.NewPCG(w.cfg.Seed, uint64(w.id)))
+	w.elapsed = make([]time.Duration, len(w.workloads))
+	w.typeNb = make([]int, len(w.types))
+	w.typeElapsed = make([]time.Duration, len(w.types))
+	w.queue, w.service, w.total = NewHistogram(), NewHistogram(), NewHistogram()
+	return nil
+}
+
+// Step executes one transaction (i.e. one step) of a given type, and
+// accumulates the elapsed time of each algorithm if the transaction is measured
+func (w *worker) Step(t int, measuring bool) {
+	t0 := time.Now()
+	for _, i := range w.types[t].runs {
+		w.workloads[i].Bench.Run()
+		t1 := time.Now()
+		if measuring {
+			w.elapsed[i] += t1.Sub(t0)
+		}
+		t0 = t1
+	}
+}
+
+// Exit sends the throughput of the worker, the time spent in each algorithm,
+// and the latency histograms back to the driver
+func (w *worker) Exit() {
+	w.output <- workerResult{
+		nb:          w.nb,
+		elapsed:     w.elapsed,
+		typeNb:      w.typeNb,
+		typeElapsed: w.typeElapsed,
+		queue:       w.queue,
+		service:     w.service,
+		total:       w.total,
+	}
+}
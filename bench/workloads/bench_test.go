@@ -1,4 +1,4 @@
-package main
+package workloads
 
 import (
 	"testing"
@@ -119,25 +119,10 @@ func BenchmarkHaversine(b *testing.B) {
 }
 
 func BenchmarkAll(b *testing.B) {
-	benchmarks := []Benchmark{
-		NewBenchCompression(),
-		NewBenchSort(),
-		NewBenchAwk(),
-		NewBenchJson(),
-		NewBenchBtree(),
-		NewBenchSimulation(),
-		NewBench8Queens(),
-		NewBenchMemory(),
-		NewBenchImage(),
-		NewBenchCrypto(),
-		NewBenchPearls(),
-		NewBenchGraph(),
-		NewBenchLogging(),
-		NewBenchHaversine(),
-	}
-	for n := 0; n < b.N; n++ {
-		for _, x := range benchmarks {
-			x.Run()
+	workloads := Canonical()
+	for n := 0; n < b.N; n++ {
+		for _, x := range workloads {
+			x.Bench.Run()
 		}
 	}
 }
@@ -146,3 +131,15 @@ func TestImage(t *testing.T) {
 	b := NewBenchImage()
 	b.Run()
 }
+
+func TestCanonical(t *testing.T) {
+	w := Canonical()
+	if len(w) != len(Defs()) || w[0].Name != "compression" || w[13].Name != "haversine" {
+		t.Fatalf("unexpected canonical workloads: %+v", w)
+	}
+	for _, x := range w {
+		if x.Bench == nil || x.Weight != 1 {
+			t.Errorf("unexpected workload: %+v", x)
+		}
+	}
+}
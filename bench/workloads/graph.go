@@ -1,4 +1,4 @@
-package main
+package workloads
 
 import (
 	"container/heap"
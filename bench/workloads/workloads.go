@@ -0,0 +1,52 @@
+// Package workloads contains the canonical workloads of cpubench1a: the
+// algorithms of the standard benchmark, with their datasets. They are run by
+// the benchmark engine (see the bench package), so another program can run
+// the standard benchmark:
+//
+//	res, err := bench.Run(ctx, bench.Config{Workloads: workloads.Canonical})
+package workloads
+
+import "github.com/AmadeusITGroup/cpubench1a/bench"
+
+// Def is the definition of an algorithm of the standard benchmark. The weight
+// is its default number of runs per transaction.
+type Def struct {
+	Name     string
+	Category string
+	Weight   int
+	New      func() bench.Benchmark
+}
+
+// defs are the algorithms of the standard benchmark, in the order of the transaction
+var defs = []Def{
+	{Name: "compression", Category: "codec", Weight: 1, New: func() bench.Benchmark { return NewBenchCompression() }},
+	{Name: "sort", Category: "data", Weight: 1, New: func() bench.Benchmark { return NewBenchSort() }},
+	{Name: "awk", Category: "text", Weight: 1, New: func() bench.Benchmark { return NewBenchAwk() }},
+	{Name: "json", Category: "text", Weight: 1, New: func() bench.Benchmark { return NewBenchJson() }},
+	{Name: "btree", Category: "data", Weight: 1, New: func() bench.Benchmark { return NewBenchBtree() }},
+	{Name: "simulation", Category: "compute", Weight: 1, New: func() bench.Benchmark { return NewBenchSimulation() }},
+	{Name: "8queens", Category: "compute", Weight: 1, New: func() bench.Benchmark { return NewBench8Queens() }},
+	{Name: "memory", Category: "data", Weight: 1, New: func() bench.Benchmark { return NewBenchMemory() }},
+	{Name: "image", Category: "codec", Weight: 1, New: func() bench.Benchmark { return NewBenchImage() }},
+	{Name: "crypto", Category: "codec", Weight: 1, New: func() bench.Benchmark { return NewBenchCrypto() }},
+	{Name: "pearls", Category: "compute", Weight: 1, New: func() bench.Benchmark { return NewBenchPearls() }},
+	{Name: "graph", Category: "data", Weight: 1, New: func() bench.Benchmark { return NewBenchGraph() }},
+	{Name: "logging", Category: "text", Weight: 1, New: func() bench.Benchmark { return NewBenchLogging() }},
+	{Name: "haversine", Category: "compute", Weight: 1, New: func() bench.Benchmark { return NewBenchHaversine() }},
+}
+
+// Defs returns the algorithms of the standard benchmark, in the order of the transaction
+func Defs() []Def {
+	return append([]Def(nil), defs...)
+}
+
+// Canonical builds the algorithms of the standard benchmark for a worker,
+// with their default weight. It is meant to be used as the Workloads function
+// of the configuration of the engine.
+func Canonical() []bench.Workload {
+	res := make([]bench.Workload, len(defs))
+	for i, def := range defs {
+		res[i] = bench.Workload{Name: def.Name, Bench: def.New(), Weight: def.Weight}
+	}
+	return res
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"log"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// averageBreakdown calculates the average cost of each algorithm over several iterations
+func averageBreakdown(recs []ResultRecord) []bench.WorkloadCost {
+	var res []bench.WorkloadCost
+	n := 0
+	for _, rec := range recs {
+		if len(rec.Breakdown) == 0 {
+			continue
+		}
+		if res == nil {
+			res = make([]bench.WorkloadCost, len(rec.Breakdown))
+			for i, c := range rec.Breakdown {
+				res[i].Name = c.Name
+			}
+		}
+		if len(rec.Breakdown) != len(res) {
+			continue
+		}
+		for i, c := range rec.Breakdown {
+			res[i].Cost += c.Cost
+		}
+		n++
+	}
+	for i := range res {
+		res[i].Cost /= float64(n)
+	}
+	return res
+}
+
+// displayBreakdown displays the share and the cost per transaction of each algorithm
+func displayBreakdown(title string, b []bench.WorkloadCost) {
+	if len(b) == 0 {
+		return
+	}
+	total := 0.0
+	for _, c := range b {
+		total += c.Cost
+	}
+	log.Print(title)
+	for _, c := range b {
+		log.Printf("    %-12s %6.2f%% %12.1f us/transaction", c.Name, 100.0*c.Cost/total, c.Cost/1000.0)
+	}
+	log.Printf("    %-12s %6.2f%% %12.1f us/transaction", "total", 100.0, total/1000.0)
+	log.Print()
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand/v2"
+	"sort"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// Parameters of the statistical comparison
+const (
+	compareResamples = 10000
+	compareLevel     = 0.95
+	compareAlpha     = 0.05
+)
+
+// Comparison is the result of the statistical comparison of two series of results
+type Comparison struct {
+	MedianA float64
+	MedianB float64
+	Ratio   float64
+	Low     float64
+	High    float64
+	U       float64
+	P       float64
+}
+
+// compareResults compares two result files (A is the reference, B is the candidate)
+func compareResults(args []string) error {
+
+	if len(args) != 2 {
+		return errors.New("two result files are expected to compare")
+	}
+
+	// Load both result files
+	var files [2]*ResultFile
+	for i, name := range args {
+		rf, err := LoadResult(name)
+		if err != nil {
+			return err
+		}
+		files[i] = rf
+	}
+	a, b := files[0], files[1]
+
+	// Scores of different versions of the benchmark must not be compared
+	va, vb := a.Header.Version, b.Header.Version
+	switch {
+	case va != "" && vb != "" && va != vb:
+		return fmt.Errorf("cannot compare results of version %s and %s", va, vb)
+	case va == "" || vb == "":
+		log.Print("Warning: legacy result file, the version of the benchmark cannot be checked")
+	}
+
+	// Results of different workload mixes must not be compared either
+	mixa, err := a.Mix()
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	mixb, err := b.Mix()
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+	if mixa != mixb {
+		return fmt.Errorf("cannot compare results of different workload mixes (%s and %s)", describeMix(mixa), describeMix(mixb))
+	}
+
+	// The placement of the workers is compared on its own: measuring its effect is a valid comparison
+	pina, err := a.Pin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	pinb, err := b.Pin()
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+	if pina != pinb {
+		log.Printf("Warning: different placements of the workers (A: %s, B: %s)", describePin(pina), describePin(pinb))
+	}
+
+	log.Print("Comparison")
+	log.Print("==========")
+	log.Print()
+	log.Printf("A: %s (%s)", args[0], describeHeader(a.Header))
+	log.Printf("B: %s (%s)", args[1], describeHeader(b.Header))
+	log.Print()
+
+	ma, mb := a.Map(), b.Map()
+	displayComparison("Single thread", ma[1], mb[1])
+	displayComparison("Multi-thread", ma[multiWorkers(ma)], mb[multiWorkers(mb)])
+	return nil
+}
+
+// describeMix returns a short description of the workload mix recorded with the results
+func describeMix(mix string) string {
+	if mix == "" {
+		return "canonical"
+	}
+	return mix
+}
+
+// describePin returns a short description of the pinning policy recorded with the results
+func describePin(pin string) string {
+	if pin == "" {
+		return "not pinned"
+	}
+	return "pinned " + pin
+}
+
+// describeHeader returns a short description of the origin of a result file
+func describeHeader(h ResultHeader) string {
+	if h.Format < 2 {
+		return "legacy format"
+	}
+	return fmt.Sprintf("version %s on %s %s/%s", h.Version, h.Host, h.OS, h.Arch)
+}
+
+// multiWorkers returns the number of workers of the multi-threaded results.
+// The machines can have a different number of threads, so this is simply the
+// highest number of workers (or 0 if there are only single-threaded results).
+func multiWorkers(m ResultMap) int {
+	res := 0
+	for w := range m {
+		if w > 1 && w > res {
+			res = w
+		}
+	}
+	return res
+}
+
+// displayComparison compares two series of results, and displays the outcome
+func displayComparison(title string, a, b []float64) {
+
+	log.Print(title)
+	if len(a) == 0 || len(b) == 0 {
+		log.Print("    No result to compare")
+		log.Print()
+		return
+	}
+
+	c := compare(a, b)
+	verdict := "not significant"
+	if c.P < compareAlpha {
+		verdict = "significant"
+	}
+
+	log.Printf("     Median A: %.6f (%d iterations)", c.MedianA, len(a))
+	log.Printf("     Median B: %.6f (%d iterations)", c.MedianB, len(b))
+	log.Printf("    Ratio B/A: %.4f (%+.1f%%), %.0f%% CI [%+.1f%%, %+.1f%%]",
+		c.Ratio, 100.0*(c.Ratio-1.0), 100.0*compareLevel, 100.0*(c.Low-1.0), 100.0*(c.High-1.0))
+	log.Printf("    Mann-Whitney U: %.1f, p-value: %.4f (%s)", c.U, c.P, verdict)
+	log.Print()
+}
+
+// compare calculates the ratio of the medians of two series of results (b/a), its
+// confidence interval, and the significance of the difference
+func compare(a, b []float64) Comparison {
+	c := Comparison{
+		MedianA: bench.Median(a),
+		MedianB: bench.Median(b),
+	}
+	c.Ratio = c.MedianB / c.MedianA
+	c.Low, c.High = bootstrapRatio(a, b, compareResamples, compareLevel)
+	c.U, c.P = mannWhitney(a, b)
+	return c
+}
+
+// bootstrapRatio calculates a percentile bootstrap confidence interval of the
+// ratio of the medians of two series (b/a). The random generator is seeded with
+// a constant, so the result is reproducible.
+func bootstrapRatio(a, b []float64, n int, level float64) (float64, float64) {
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	ra := make([]float64, len(a))
+	rb := make([]float64, len(b))
+	ratios := make([]float64, n)
+
+	for i := range ratios {
+		for j := range ra {
+			ra[j] = a[rnd.IntN(len(a))]
+		}
+		for j := range rb {
+			rb[j] = b[rnd.IntN(len(b))]
+		}
+		ratios[i] = bench.Median(rb) / bench.Median(ra)
+	}
+
+	sort.Float64s(ratios)
+	return bench.Quantile(ratios, (1.0-level)/2.0), bench.Quantile(ratios, (1.0+level)/2.0)
+}
+
+// mannWhitney runs a two-sided Mann-Whitney U test on two series. It returns the
+// U statistic of the first series and the p-value, calculated with the normal
+// approximation (with tie and continuity corrections).
+func mannWhitney(a, b []float64) (float64, float64) {
+
+	// Rank the merged series, averaging the ranks of ties
+	type item struct {
+		v     float64
+		first bool
+	}
+	all := make([]item, 0, len(a)+len(b))
+	for _, x := range a {
+		all = append(all, item{x, true})
+	}
+	for _, x := range b {
+		all = append(all, item{x, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	r1, ties := 0.0, 0.0
+	for i := 0; i < len(all); {
+		j := i + 1
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2.0
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	// Calculate the statistic and its normal approximation
+	n1, n2 := float64(len(a)), float64(len(b))
+	n := n1 + n2
+	u := r1 - n1*(n1+1.0)/2.0
+	mu := n1 * n2 / 2.0
+	sigma := math.Sqrt(n1 * n2 / 12.0 * ((n + 1.0) - ties/(n*(n-1.0))))
+	if sigma == 0.0 {
+		return u, 1.0
+	}
+	z := (math.Abs(u-mu) - 0.5) / sigma
+	if z < 0.0 {
+		z = 0.0
+	}
+	return u, math.Erfc(z / math.Sqrt2)
+}
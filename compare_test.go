@@ -0,0 +1,36 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMannWhitney(t *testing.T) {
+
+	// Fully separated samples
+	u, p := mannWhitney([]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10})
+	if u != 0.0 || math.Abs(p-0.0122) > 0.0005 {
+		t.Errorf("u=%v p=%v, expected u=0 p=0.0122", u, p)
+	}
+
+	// Identical samples
+	u, p = mannWhitney([]float64{1, 2, 3}, []float64{1, 2, 3})
+	if u != 4.5 || p != 1.0 {
+		t.Errorf("u=%v p=%v, expected u=4.5 p=1", u, p)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	a := []float64{100, 101, 99, 100, 102, 98, 100, 101, 99, 100}
+	b := []float64{112, 113, 111, 112, 114, 110, 112, 113, 111, 112}
+	c := compare(a, b)
+	if math.Abs(c.Ratio-1.12) > 1e-9 {
+		t.Errorf("ratio=%v, expected 1.12", c.Ratio)
+	}
+	if c.Low > c.Ratio || c.High < c.Ratio || c.Low < 1.08 || c.High > 1.16 {
+		t.Errorf("unexpected confidence interval [%v, %v]", c.Low, c.High)
+	}
+	if c.P >= compareAlpha {
+		t.Errorf("difference should be significant, p=%v", c.P)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"slices"
+	"strconv"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// sweepClassGap is the relative gap (in %) between the sorted throughputs of
+// two CPUs above which they belong to different performance classes
+const sweepClassGap = 5.0
+
+// CPUPoint is the single-threaded throughput of the benchmark pinned to a logical CPU.
+// The performance classes are numbered from the fastest CPUs (class 0).
+type CPUPoint struct {
+	CPU        int     `json:"cpu"`
+	Socket     string  `json:"socket"`
+	CoreID     string  `json:"core_id"`
+	Node       int     `json:"node"`
+	Throughput float64 `json:"throughput"`
+	Class      int     `json:"class"`
+	Outlier    bool    `json:"outlier,omitempty"`
+}
+
+// cpuSweep runs the single-threaded benchmark pinned to each logical CPU in turn
+func cpuSweep() error {
+
+	report, err := startMode("cpusweep")
+	if err != nil {
+		return err
+	}
+	if !affinitySupported {
+		return fmt.Errorf("the CPU sweep is not supported on %s", runtime.GOOS)
+	}
+	topo := report.CPU.Numa
+	if len(topo) == 0 {
+		if topo, err = cpuTopology(); err != nil {
+			return err
+		}
+	}
+	if len(topo) == 0 {
+		return errors.New("no CPU topology available")
+	}
+
+	// Run a short benchmark for each CPU
+	ph := benchPhase{Title: "Single threaded performance per CPU"}
+	for _, c := range topo {
+		ph.Points = append(ph.Points, benchPoint{CPUs: strconv.Itoa(c.CPU), Threads: 1, Workers: 1, Duration: *flagSweepDur, Iterations: 1})
+	}
+	rf, err := runPhases(report, []benchPhase{ph})
+	if err != nil {
+		return err
+	}
+	report.Sweep = NewCPUSweep(rf, topo)
+	displayCPUSweep(report.Sweep)
+	return endMode(report)
+}
+
+// NewCPUSweep associates the iterations to the CPUs, clusters the CPUs in
+// performance classes, and flags the outliers
+func NewCPUSweep(rf *ResultFile, topo []NumaCPU) []CPUPoint {
+
+	var res []CPUPoint
+	for _, c := range topo {
+		pt := CPUPoint{CPU: c.CPU, Socket: c.Socket, CoreID: c.CoreID, Node: c.Node}
+		pt.Throughput = rf.PointStat(strconv.Itoa(c.CPU), 1).Max
+		res = append(res, pt)
+	}
+
+	// Outliers are detected on the whole set of CPUs
+	r := make([]float64, len(res))
+	for i, pt := range res {
+		r[i] = pt.Throughput
+	}
+	for _, i := range bench.ComputeStat(r).Outliers {
+		res[i].Outlier = true
+	}
+
+	// A new class starts at each significant gap between the sorted throughputs
+	order := make([]int, len(res))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return cmp.Compare(res[b].Throughput, res[a].Throughput) })
+	for k := 1; k < len(order); k++ {
+		prev, cur := res[order[k-1]], res[order[k]]
+		res[order[k]].Class = prev.Class
+		if prev.Throughput > 0.0 && 100.0*(prev.Throughput-cur.Throughput)/prev.Throughput > sweepClassGap {
+			res[order[k]].Class++
+		}
+	}
+	return res
+}
+
+// displayCPUSweep displays the throughput of each CPU and the performance classes
+func displayCPUSweep(points []CPUPoint) {
+	log.Print("CPU sweep results")
+	log.Print("=================")
+	log.Print()
+	log.Print("    CPU Socket CoreId Node     Throughput  Class")
+	classes := map[int][]CPUPoint{}
+	for _, pt := range points {
+		mark := ""
+		if pt.Outlier {
+			mark = "  OUTLIER"
+		}
+		log.Printf("    %3d %6s %6s %4d %14.6f %6d%s", pt.CPU, pt.Socket, pt.CoreID, pt.Node, pt.Throughput, pt.Class, mark)
+		classes[pt.Class] = append(classes[pt.Class], pt)
+	}
+	log.Print()
+	for _, c := range sortedKeys(classes) {
+		var cpus []int
+		var r []float64
+		for _, pt := range classes[c] {
+			cpus = append(cpus, pt.CPU)
+			r = append(r, pt.Throughput)
+		}
+		slices.Sort(cpus)
+		s := bench.ComputeStat(r)
+		log.Printf("Class %d: %d CPUs, throughput %.6f to %.6f, CPUs %s", c, len(cpus), s.Min, s.Max, formatCPUList(cpus))
+	}
+	log.Print()
+}
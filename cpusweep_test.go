@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestNewCPUSweep(t *testing.T) {
+
+	// A hybrid CPU with 4 fast cores, 4 slow cores, and a degraded fast core
+	tp := []float64{100, 101, 99, 70, 40, 41, 40, 42}
+	var topo []NumaCPU
+	rf := &ResultFile{}
+	for c, x := range tp {
+		topo = append(topo, NumaCPU{CPU: c, Socket: "0", CoreID: strconv.Itoa(c)})
+		rf.Records = append(rf.Records, ResultRecord{Workers: 1, CPUSet: strconv.Itoa(c), Throughput: x})
+	}
+
+	res := NewCPUSweep(rf, topo)
+	expected := []int{0, 0, 0, 1, 2, 2, 2, 2}
+	for i, pt := range res {
+		if pt.Class != expected[i] || pt.Throughput != tp[i] {
+			t.Errorf("CPU %d: class=%d throughput=%v, expected class %d", pt.CPU, pt.Class, pt.Throughput, expected[i])
+		}
+	}
+}
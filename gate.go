@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// Exit codes returned when the regression gate fails. They are distinct from
+// the codes of a generic error (1), an invalid command line (2, returned by the
+// flag package), and an interruption (130).
+const (
+	ExitSingleFailed = 10
+	ExitMultiFailed  = 11
+	ExitBothFailed   = 12
+)
+
+// GateError is returned when the results do not satisfy the qualification thresholds
+type GateError struct {
+	Code     int
+	Failures []string
+}
+
+// Error returns the list of failed criteria
+func (e *GateError) Error() string {
+	return "regression gate failed: " + strings.Join(e.Failures, "; ")
+}
+
+// gateEnabled returns true if any qualification threshold has been set
+func gateEnabled() bool {
+	return *flagMinSingle > 0.0 || *flagMinMulti > 0.0 || *flagMaxDrop > 0.0
+}
+
+// checkGate evaluates the qualification thresholds against the score (i.e. the
+// maximum throughput) of the single-threaded and multi-threaded results. The
+// relative threshold requires a baseline.
+func checkGate(m ResultMap, workers int, base *Baseline) error {
+
+	if !gateEnabled() {
+		return nil
+	}
+
+	single, multi := bench.ComputeStat(m[1]).Max, bench.ComputeStat(m[workers]).Max
+	var failures []string
+
+	check := func(title string, score, min float64, ref float64) bool {
+		failed := false
+		if min > 0.0 && score < min {
+			failures = append(failures, fmt.Sprintf("%s throughput %.6f is below %.6f", title, score, min))
+			failed = true
+		}
+		if *flagMaxDrop > 0.0 && ref > 0.0 {
+			drop := 100.0 * (ref - score) / ref
+			if drop > *flagMaxDrop {
+				failures = append(failures, fmt.Sprintf("%s throughput %.6f is %.2f%% below the baseline %.6f (maximum %.2f%%)", title, score, drop, ref, *flagMaxDrop))
+				failed = true
+			}
+		}
+		return failed
+	}
+
+	var refSingle, refMulti float64
+	if base != nil {
+		refSingle, refMulti = base.Single, base.Multi
+	}
+	singleFailed := check("single thread", single, *flagMinSingle, refSingle)
+	multiFailed := check("multi-thread", multi, *flagMinMulti, refMulti)
+
+	log.Print("Regression gate")
+	for _, f := range failures {
+		log.Printf("    FAILED: %s", f)
+	}
+	switch {
+	case singleFailed && multiFailed:
+		return &GateError{Code: ExitBothFailed, Failures: failures}
+	case singleFailed:
+		return &GateError{Code: ExitSingleFailed, Failures: failures}
+	case multiFailed:
+		return &GateError{Code: ExitMultiFailed, Failures: failures}
+	}
+	log.Print("    PASSED")
+	log.Print()
+	return nil
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheckGate(t *testing.T) {
+	minSingle, minMulti, maxDrop := *flagMinSingle, *flagMinMulti, *flagMaxDrop
+	defer func() { *flagMinSingle, *flagMinMulti, *flagMaxDrop = minSingle, minMulti, maxDrop }()
+
+	m := ResultMap{1: {90.0, 100.0}, 8: {700.0, 800.0}}
+	base := &Baseline{Single: 110.0, Multi: 820.0}
+	tests := []struct {
+		minSingle, minMulti, maxDrop float64
+		expected                     int
+	}{
+		{0.0, 0.0, 0.0, 0},
+		{100.0, 800.0, 0.0, 0},
+		{101.0, 0.0, 0.0, ExitSingleFailed},
+		{0.0, 801.0, 0.0, ExitMultiFailed},
+		{101.0, 801.0, 0.0, ExitBothFailed},
+		{0.0, 0.0, 5.0, ExitSingleFailed},
+		{0.0, 0.0, 10.0, 0},
+		{0.0, 900.0, 5.0, ExitBothFailed},
+	}
+	for _, tt := range tests {
+		*flagMinSingle, *flagMinMulti, *flagMaxDrop = tt.minSingle, tt.minMulti, tt.maxDrop
+		err := checkGate(m, 8, base)
+		var gate *GateError
+		switch {
+		case tt.expected == 0 && err != nil:
+			t.Errorf("%+v: unexpected failure: %v", tt, err)
+		case tt.expected != 0 && (!errors.As(err, &gate) || gate.Code != tt.expected):
+			t.Errorf("%+v: expected exit code %d, got %v", tt, tt.expected, err)
+		}
+	}
+
+	// The relative threshold is ignored without baseline
+	*flagMinSingle, *flagMinMulti, *flagMaxDrop = 0.0, 0.0, 5.0
+	if err := checkGate(m, 8, nil); err != nil {
+		t.Errorf("unexpected failure without baseline: %v", err)
+	}
+
+	// The exit codes must not collide with the other exit codes
+	for _, code := range []int{ExitSingleFailed, ExitMultiFailed, ExitBothFailed} {
+		if code <= 2 || code == ExitInterrupted {
+			t.Errorf("exit code %d is already used", code)
+		}
+	}
+}
@@ -1,4 +1,4 @@
-module cpubench1a
+module github.com/AmadeusITGroup/cpubench1a
 
 go 1.25
 
@@ -7,6 +7,7 @@ require (
 	github.com/shirou/gopsutil/v3 v3.24.5
 	github.com/tidwall/btree v1.8.1
 	github.com/tidwall/gjson v1.18.0
+	golang.org/x/sys v0.37.0
 )
 
 require (
@@ -19,5 +20,4 @@ require (
 	github.com/tklauser/go-sysconf v0.3.15 // indirect
 	github.com/tklauser/numcpus v0.10.0 // indirect
 	github.com/yusufpapurcu/wmi v1.2.4 // indirect
-	golang.org/x/sys v0.37.0 // indirect
 )
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// historyFile is the name of the history file in the history directory
+const historyFile = "history.jsonl"
+
+// HistoryEntry is the summary of a benchmark run, as recorded in the local history
+type HistoryEntry struct {
+	Time    time.Time   `json:"time"`
+	Host    string      `json:"host"`
+	Version string      `json:"version"`
+	Mode    string      `json:"mode"`
+	CPU     string      `json:"cpu"`
+	Threads string      `json:"threads"`
+	Workers string      `json:"workers"`
+	Mix     string      `json:"mix,omitempty"`
+	Pin     string      `json:"pin,omitempty"`
+	Single  *bench.Stat `json:"single,omitempty"`
+	Multi   *bench.Stat `json:"multi,omitempty"`
+	Score   *Score      `json:"score,omitempty"`
+	OLTP    []OLTPPoint `json:"oltp,omitempty"`
+}
+
+// historyDir returns the directory of the local history
+func historyDir() (string, error) {
+	if *flagHistDir != "" {
+		return *flagHistDir, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "cpubench1a"), nil
+}
+
+// NewHistoryEntry summarizes a report
+func NewHistoryEntry(r *Report) HistoryEntry {
+	e := HistoryEntry{
+		Time:    r.End,
+		Host:    r.Host,
+		Version: r.Version,
+		Mode:    r.Mode,
+		CPU:     r.CPU.Model,
+		Threads: r.Flags["threads"],
+		Workers: r.Flags["workers"],
+		Mix:     r.Label,
+		Pin:     r.Pin,
+		Score:   r.Score,
+		OLTP:    r.OLTP,
+	}
+	if r.Single != nil {
+		e.Single = &r.Single.Stat
+	}
+	if r.Multi != nil {
+		e.Multi = &r.Multi.Stat
+	}
+	return e
+}
+
+// AppendHistory records the report in the local history. The history is an
+// append-only file of JSON lines.
+func AppendHistory(r *Report) error {
+
+	dir, err := historyDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(NewHistoryEntry(r))
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	f, err := os.OpenFile(filepath.Join(dir, historyFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadHistory reads all the entries of the local history
+func LoadHistory() ([]HistoryEntry, error) {
+
+	dir, err := historyDir()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(filepath.Join(dir, historyFile))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var res []HistoryEntry
+	scan := bufio.NewScanner(f)
+	scan.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scan.Scan(); line++ {
+		if strings.TrimSpace(scan.Text()) == "" {
+			continue
+		}
+		var e HistoryEntry
+		if err := json.Unmarshal(scan.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("%s line %d: %w", f.Name(), line, err)
+		}
+		res = append(res, e)
+	}
+	return res, scan.Err()
+}
+
+// historyFilter selects history entries
+type historyFilter struct {
+	host    string
+	cpu     string
+	version string
+	mode    string
+}
+
+// newHistoryFilter builds a filter from key=value arguments
+func newHistoryFilter(args []string) (historyFilter, error) {
+	var res historyFilter
+	for _, a := range args {
+		k, v, ok := strings.Cut(a, "=")
+		if !ok {
+			return res, fmt.Errorf("invalid history filter %q (key=value expected)", a)
+		}
+		switch k {
+		case "host":
+			res.host = v
+		case "cpu":
+			res.cpu = strings.ToLower(v)
+		case "version":
+			res.version = v
+		case "mode":
+			res.mode = v
+		default:
+			return res, fmt.Errorf("unknown history filter %q (host, cpu, version or mode expected)", k)
+		}
+	}
+	return res, nil
+}
+
+// match returns true if the entry is selected by the filter.
+// The CPU model is matched on a case insensitive substring.
+func (f historyFilter) match(e HistoryEntry) bool {
+	return (f.host == "" || f.host == e.Host) &&
+		(f.cpu == "" || strings.Contains(strings.ToLower(e.CPU), f.cpu)) &&
+		(f.version == "" || f.version == e.Version) &&
+		(f.mode == "" || f.mode == e.Mode)
+}
+
+// showHistory lists the selected history entries, grouped by host, and
+// displays the trend of the single-threaded and multi-threaded throughput
+func showHistory(args []string) error {
+
+	filter, err := newHistoryFilter(args)
+	if err != nil {
+		return err
+	}
+	entries, err := LoadHistory()
+	if err != nil {
+		return err
+	}
+
+	// Group the entries by host (they are already sorted by time)
+	hosts := map[string][]HistoryEntry{}
+	for _, e := range entries {
+		if filter.match(e) {
+			hosts[e.Host] = append(hosts[e.Host], e)
+		}
+	}
+	if len(hosts) == 0 {
+		log.Print("No matching entry in the history")
+		return nil
+	}
+	names := make([]string, 0, len(hosts))
+	for h := range hosts {
+		names = append(names, h)
+	}
+	sort.Strings(names)
+
+	log.Print("History")
+	log.Print("=======")
+	log.Print()
+	for _, h := range names {
+		log.Printf("Host: %s", h)
+		for _, e := range hosts[h] {
+			mix := ""
+			if e.Mix != "" {
+				mix = "  custom mix: " + e.Mix
+			}
+			if e.Pin != "" {
+				mix += "  pinned: " + e.Pin
+			}
+			switch {
+			case e.Single != nil || e.Multi != nil:
+				log.Printf("    %s  %-5s %-6s %s  single: %12.6f  multi: %12.6f%s",
+					e.Time.Format("2006-01-02 15:04"), e.Version, e.Mode, e.CPU, historyMax(e.Single), historyMax(e.Multi), mix)
+			default:
+				log.Printf("    %s  %-5s %-6s %s  %d OLTP steps%s",
+					e.Time.Format("2006-01-02 15:04"), e.Version, e.Mode, e.CPU, len(e.OLTP), mix)
+			}
+		}
+		displayTrend("Single thread", hosts[h], func(e HistoryEntry) *bench.Stat { return e.Single })
+		displayTrend("Multi-thread", hosts[h], func(e HistoryEntry) *bench.Stat { return e.Multi })
+		log.Print()
+	}
+	return nil
+}
+
+// historyMax returns the score (i.e. the maximum throughput) of a history entry
+func historyMax(s *bench.Stat) float64 {
+	if s == nil {
+		return 0.0
+	}
+	return s.Max
+}
+
+// displayTrend displays the evolution of the maximum throughput over time.
+// Only the entries of the same version, workload mix and pinning policy as the
+// last entry are considered, since such results must not be compared.
+func displayTrend(title string, entries []HistoryEntry, get func(HistoryEntry) *bench.Stat) {
+
+	var ts, xs []float64
+	var last *HistoryEntry
+	for i := len(entries) - 1; i >= 0; i-- {
+		s := get(entries[i])
+		if s == nil || s.N == 0 {
+			continue
+		}
+		if last == nil {
+			last = &entries[i]
+		}
+		if !last.sameSetup(entries[i]) {
+			continue
+		}
+		ts = append(ts, entries[i].Time.Sub(entries[0].Time).Hours()/24.0)
+		xs = append(xs, s.Max)
+	}
+	if len(xs) < 2 {
+		return
+	}
+
+	// The entries have been collected in reverse order. The slope is only
+	// meaningful if the runs span over more than a day.
+	first := xs[len(xs)-1]
+	msg := fmt.Sprintf("    %s trend (version %s, %d runs): %+.2f%% since first run", title, last.Version, len(xs), 100.0*(xs[0]-first)/first)
+	if ts[0]-ts[len(ts)-1] >= 1.0 {
+		msg += fmt.Sprintf(", %+.2f%% per 30 days", 100.0*30.0*linearSlope(ts, xs)/bench.Average(xs))
+	}
+	log.Print(msg)
+}
+
+// sameSetup returns true if the results of two entries can be compared: they
+// are measured with the same version, workload mix and pinning policy
+func (e *HistoryEntry) sameSetup(x HistoryEntry) bool {
+	return e.Version == x.Version && e.Mix == x.Mix && e.Pin == x.Pin
+}
+
+// linearSlope calculates the slope of the least squares regression line
+func linearSlope(xs, ys []float64) float64 {
+	mx, my := bench.Average(xs), bench.Average(ys)
+	num, den := 0.0, 0.0
+	for i := range xs {
+		num += (xs[i] - mx) * (ys[i] - my)
+		den += (xs[i] - mx) * (xs[i] - mx)
+	}
+	if den == 0.0 {
+		return 0.0
+	}
+	return num / den
+}
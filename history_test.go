@@ -0,0 +1,88 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+func TestHistory(t *testing.T) {
+	dir := *flagHistDir
+	*flagHistDir = t.TempDir()
+	defer func() { *flagHistDir = dir }()
+
+	if entries, err := LoadHistory(); err != nil || len(entries) != 0 {
+		t.Fatalf("empty history expected: %v, %v", entries, err)
+	}
+
+	// The zero throughput iteration gives a non-finite geometric mean
+	r := testReport()
+	r.Host, r.Pin = "host1", "compact"
+	r.Multi = &ReportSeries{Workers: 8, Throughput: []float64{0.0, 800.0}, Stat: bench.ComputeStat([]float64{0.0, 800.0})}
+	for _, host := range []string{"host1", "host2"} {
+		r.Host = host
+		if err := AppendHistory(r); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := LoadHistory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[1].Host != "host2" || entries[0].Single.Max != 110.0 || entries[0].Multi.Max != 800.0 {
+		t.Fatalf("unexpected entries: %+v", entries)
+	}
+	if entries[0].Pin != "compact" || !entries[0].sameSetup(entries[1]) {
+		t.Errorf("unexpected pinning policy: %+v", entries[0])
+	}
+	if g := entries[0].Multi.GeoMean; g != 0.0 {
+		t.Errorf("non-finite value should be recorded as null: %f", g)
+	}
+
+	f, err := newHistoryFilter([]string{"host=host2", "cpu=TEST", "version=" + Version})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.match(entries[0]) || !f.match(entries[1]) {
+		t.Errorf("unexpected filter: %+v", f)
+	}
+	for _, args := range [][]string{{"host"}, {"foo=bar"}} {
+		if _, err := newHistoryFilter(args); err == nil {
+			t.Errorf("invalid filter %v should be rejected", args)
+		}
+	}
+	if err := showHistory(nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestSameSetup(t *testing.T) {
+	e := HistoryEntry{Version: Version, Mix: "json=1"}
+	tests := []struct {
+		x        HistoryEntry
+		expected bool
+	}{
+		{HistoryEntry{Version: Version, Mix: "json=1", Host: "other"}, true},
+		{HistoryEntry{Version: "5.0", Mix: "json=1"}, false},
+		{HistoryEntry{Version: Version}, false},
+		{HistoryEntry{Version: Version, Mix: "json=1", Pin: "compact"}, false},
+	}
+	for _, tt := range tests {
+		if res := e.sameSetup(tt.x); res != tt.expected {
+			t.Errorf("sameSetup(%+v) = %v, expected %v", tt.x, res, tt.expected)
+		}
+	}
+}
+
+func TestLinearSlope(t *testing.T) {
+	xs := []float64{0.0, 1.0, 2.0, 3.0}
+	ys := []float64{10.0, 12.0, 14.0, 16.0}
+	if s := linearSlope(xs, ys); math.Abs(s-2.0) > 1e-9 {
+		t.Errorf("slope=%f, expected 2", s)
+	}
+	if s := linearSlope([]float64{1.0, 1.0}, ys[:2]); s != 0.0 {
+		t.Errorf("slope=%f, expected 0", s)
+	}
+}
@@ -0,0 +1,257 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"strings"
+)
+
+// Dimensions of the SVG charts
+const (
+	chartWidth   = 640.0
+	chartHeight  = 320.0
+	chartMargin  = 50.0
+	chartMarginR = 20.0
+)
+
+// htmlTemplate is the template of the standalone HTML report. It does not
+// reference any external asset: the charts are inline SVG.
+var htmlTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"f2":  func(x float64) string { return fmt.Sprintf("%.2f", x) },
+	"f3":  func(x float64) string { return fmt.Sprintf("%.3f", x) },
+	"pct": func(x float64) string { return fmt.Sprintf("%.1f", 100.0*x) },
+	"ms":  func(ns float64) string { return fmt.Sprintf("%.3f", ns/1.0e6) },
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>cpubench1a {{.R.Version}} - {{.R.CPU.Model}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #222; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #bbb; padding: 0.3em 0.8em; text-align: right; }
+th { background: #eee; }
+td.l, th.l { text-align: left; }
+svg { margin-bottom: 1.5em; }
+svg text { font-size: 11px; }
+</style>
+</head>
+<body>
+<h1>cpubench1a {{.R.Version}} ({{.R.Mode}})</h1>
+<p>From {{.R.Start.Format "2006-01-02 15:04:05"}} to {{.R.End.Format "2006-01-02 15:04:05"}}</p>
+{{if .R.Partial}}<p><b>Partial results: the benchmark has been interrupted.</b></p>{{end}}
+
+<h2>CPU</h2>
+<table>
+<tr><th class="l">Model</th><td class="l">{{with .R.CPU.Vendor}}{{.}} / {{end}}{{.R.CPU.Model}}</td></tr>
+<tr><th class="l">Max freq (as reported by OS)</th><td class="l">{{f2 .R.CPU.Mhz}} mhz</td></tr>
+<tr><th class="l">Cores</th><td class="l">{{.R.CPU.Cores}}</td></tr>
+<tr><th class="l">Threads</th><td class="l">{{.R.CPU.Threads}}</td></tr>
+<tr><th class="l">Benchmark threads / workers</th><td class="l">{{index .R.Flags "threads"}} / {{index .R.Flags "workers"}}</td></tr>
+<tr><th class="l">Workload mix</th><td class="l">{{if .R.Profile}}<b>profile</b>: {{.R.Profile.Name}}{{else if .R.Custom}}<b>custom</b>: {{.R.Label}}{{else}}canonical{{end}}</td></tr>
+</table>
+{{with .R.CPU.Numa}}
+<h2>NUMA topology</h2>
+<table>
+<tr><th>CPU</th><th>Socket</th><th>CoreId</th><th>Node</th></tr>
+{{range .}}<tr><td>{{.CPU}}</td><td>{{.Socket}}</td><td>{{.CoreID}}</td><td>{{.Node}}</td></tr>
+{{end}}</table>
+{{end}}
+{{if .Series}}
+<h2>Statistics</h2>
+<table>
+<tr><th class="l"></th>{{range .Series}}<th>{{.Title}}</th>{{end}}</tr>
+<tr><th class="l">Workers</th>{{range .Series}}<td>{{.S.Workers}}</td>{{end}}</tr>
+<tr><th class="l">Iterations</th>{{range .Series}}<td>{{.S.Stat.N}}</td>{{end}}</tr>
+<tr><th class="l">Minimum</th>{{range .Series}}<td>{{f3 .S.Stat.Min}}</td>{{end}}</tr>
+<tr><th class="l">Average</th>{{range .Series}}<td>{{f3 .S.Stat.Average}}</td>{{end}}</tr>
+<tr><th class="l">Median</th>{{range .Series}}<td>{{f3 .S.Stat.Median}}</td>{{end}}</tr>
+<tr><th class="l">Median CI</th>{{range .Series}}<td>[{{f3 .S.Stat.MedianLow}}, {{f3 .S.Stat.MedianHigh}}]</td>{{end}}</tr>
+<tr><th class="l">Geo mean</th>{{range .Series}}<td>{{f3 .S.Stat.GeoMean}}</td>{{end}}</tr>
+<tr><th class="l">Maximum</th>{{range .Series}}<td>{{f3 .S.Stat.Max}}</td>{{end}}</tr>
+<tr><th class="l">Std dev</th>{{range .Series}}<td>{{f3 .S.Stat.StdDev}}</td>{{end}}</tr>
+<tr><th class="l">CoV</th>{{range .Series}}<td>{{f2 .S.Stat.CoV}}%</td>{{end}}</tr>
+{{if .R.Score}}<tr><th class="l">Normalized score</th>{{range .Series}}<td>{{f3 .Score}}</td>{{end}}</tr>{{end}}
+</table>
+{{with .R.Score}}<p>Score relative to {{.Reference.Machine}} (version {{.Reference.Version}})</p>{{end}}
+{{range .Series}}
+<h2>{{.Title}} throughput per iteration</h2>
+{{.Chart}}
+{{with .S.Types}}
+<table>
+<tr><th>Transaction type</th><th>Throughput</th><th>Cost (ms)</th></tr>
+{{range .}}<tr><td class="l">{{.Name}}</td><td>{{f3 .Throughput}}</td><td>{{ms .Cost}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{end}}
+{{if .R.Scaling}}
+<h2>Scaling</h2>
+{{.ScalingChart}}
+<table>
+<tr><th>Workers</th><th>Throughput</th><th>Speedup</th><th>Efficiency</th></tr>
+{{range .R.Scaling}}<tr><td>{{.Workers}}</td><td>{{f3 .Throughput}}</td><td>{{f3 .Speedup}}</td><td>{{pct .Efficiency}}%</td></tr>
+{{end}}</table>
+{{if .R.Fits}}
+<table>
+<tr><th>Model</th><th>Lambda</th><th>Contention</th><th>Coherency</th><th>R2</th><th>Peak workers</th><th>Peak throughput</th></tr>
+{{range .R.Fits}}<tr><td>{{.Model}}</td><td>{{f3 .Lambda}}</td><td>{{printf "%.6f" .Sigma}}</td><td>{{printf "%.6f" .Kappa}}</td><td>{{f3 .R2}}</td><td>{{if .PeakWorkers}}{{printf "%.1f" .PeakWorkers}}{{end}}</td><td>{{if .PeakThroughput}}{{f3 .PeakThroughput}}{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{end}}
+{{with .R.NUMA}}
+<h2>NUMA nodes</h2>
+<table>
+<tr><th>Node</th><th>CPUs</th><th>Threads</th><th>Workers</th><th>Throughput</th></tr>
+{{range .Nodes}}<tr><td>{{.Node}}</td><td>{{.CPUs}}</td><td>{{.Threads}}</td><td>{{.Workers}}</td><td>{{f3 .Throughput}}</td></tr>
+{{end}}<tr><td>all</td><td></td><td>{{.All.Threads}}</td><td>{{.All.Workers}}</td><td>{{f3 .All.Throughput}}</td></tr>
+</table>
+<p>Spanning efficiency: {{pct .Efficiency}}% of the sum of the nodes ({{f3 .Sum}})</p>
+{{end}}
+{{if .R.Sweep}}
+<h2>Single-threaded throughput per CPU</h2>
+<table>
+<tr><th>CPU</th><th>Socket</th><th>CoreId</th><th>Node</th><th>Throughput</th><th>Class</th><th></th></tr>
+{{range .R.Sweep}}<tr><td>{{.CPU}}</td><td>{{.Socket}}</td><td>{{.CoreID}}</td><td>{{.Node}}</td><td>{{f3 .Throughput}}</td><td>{{.Class}}</td><td>{{if .Outlier}}outlier{{end}}</td></tr>
+{{end}}</table>
+{{end}}
+{{with .R.SMT}}
+<h2>SMT yield</h2>
+<table>
+<tr><th>Socket</th><th>CoreId</th><th>Node</th><th>CPUs</th><th>1 thread</th><th>Siblings</th><th>Yield (%)</th></tr>
+{{range .Cores}}<tr><td>{{.Socket}}</td><td>{{.CoreID}}</td><td>{{.Node}}</td><td>{{.Siblings.CPUs}}</td><td>{{f3 .Single.Throughput}}</td><td>{{f3 .Siblings.Throughput}}</td><td>{{pct .Yield}}</td></tr>
+{{end}}</table>
+<p>One thread per core: {{f3 .Physical.Throughput}}, all the threads: {{f3 .Logical.Throughput}}, overall SMT yield: {{pct .Yield}}%</p>
+{{end}}
+{{if .R.OLTP}}
+<h2>OLTP: CPU usage versus throughput</h2>
+{{.OLTPChart}}
+<table>
+<tr><th>Target TPS</th><th>Measured TPS</th><th>CPU usage (%)</th></tr>
+{{range .R.OLTP}}<tr><td>{{.TPS}}</td><td>{{f2 .Throughput}}</td><td>{{f2 .CPUUsage}}</td></tr>
+{{end}}</table>
+{{end}}
+</body>
+</html>
+`))
+
+// htmlSeries is a series of the report, as rendered in HTML, with its normalized score
+type htmlSeries struct {
+	Title string
+	S     *ReportSeries
+	Score float64
+	Chart template.HTML
+}
+
+// WriteHTML stores the report as a standalone HTML document
+func (r *Report) WriteHTML(filename string) error {
+
+	data := struct {
+		R            *Report
+		Series       []htmlSeries
+		OLTPChart    template.HTML
+		ScalingChart template.HTML
+	}{R: r}
+
+	var single, multi float64
+	if r.Score != nil {
+		single, multi = r.Score.Single, r.Score.Multi
+	}
+	for _, x := range []htmlSeries{{Title: "Single thread", S: r.Single, Score: single}, {Title: "Multi-thread", S: r.Multi, Score: multi}} {
+		if x.S == nil {
+			continue
+		}
+		var xs []float64
+		for i := range x.S.Throughput {
+			xs = append(xs, float64(i+1))
+		}
+		x.Chart = svgChart(xs, x.S.Throughput, "Iteration", "Throughput (tps)", x.S.Stat.Median)
+		data.Series = append(data.Series, x)
+	}
+
+	if len(r.OLTP) > 0 {
+		var xs, ys []float64
+		for _, pt := range r.OLTP {
+			xs = append(xs, pt.Throughput)
+			ys = append(ys, pt.CPUUsage)
+		}
+		data.OLTPChart = svgChart(xs, ys, "Measured throughput (tps)", "CPU usage (%)", math.NaN())
+	}
+
+	if len(r.Scaling) > 0 {
+		var xs, ys []float64
+		for _, pt := range r.Scaling {
+			xs = append(xs, float64(pt.Workers))
+			ys = append(ys, pt.Throughput)
+		}
+		data.ScalingChart = svgChart(xs, ys, "Workers", "Throughput (tps)", math.NaN())
+	}
+
+	var b bytes.Buffer
+	if err := htmlTemplate.Execute(&b, data); err != nil {
+		return err
+	}
+	return os.WriteFile(filename, b.Bytes(), 0644)
+}
+
+// svgChart renders a line chart with markers as an inline SVG element.
+// An horizontal reference line is drawn at the ref value, unless it is NaN.
+func svgChart(xs, ys []float64, xlabel, ylabel string, ref float64) template.HTML {
+
+	// Calculate the ranges (the Y axis always starts at 0)
+	xmin, xmax := math.Inf(1), math.Inf(-1)
+	ymax := 0.0
+	for i := range xs {
+		xmin, xmax = math.Min(xmin, xs[i]), math.Max(xmax, xs[i])
+		ymax = math.Max(ymax, ys[i])
+	}
+	if len(xs) == 0 || xmin == xmax {
+		xmin, xmax = xmin-1.0, xmax+1.0
+	}
+	if ymax == 0.0 {
+		ymax = 1.0
+	}
+	ymax *= 1.1
+	w, h := chartWidth-chartMargin-chartMarginR, chartHeight-2*chartMargin
+	px := func(x float64) float64 { return chartMargin + (x-xmin)/(xmax-xmin)*w }
+	py := func(y float64) float64 { return chartMargin + h - y/ymax*h }
+
+	var s strings.Builder
+	fmt.Fprintf(&s, `<svg xmlns="http://www.w3.org/2000/svg" width="%.0f" height="%.0f">`, chartWidth, chartHeight)
+
+	// Axes and grid
+	for i := 0; i <= 4; i++ {
+		y := ymax * float64(i) / 4.0
+		fmt.Fprintf(&s, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, px(xmin), py(y), px(xmax), py(y))
+		fmt.Fprintf(&s, `<text x="%.1f" y="%.1f" text-anchor="end">%.1f</text>`, chartMargin-4, py(y)+4, y)
+	}
+	fmt.Fprintf(&s, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#000"/>`, px(xmin), py(0), px(xmax), py(0))
+	fmt.Fprintf(&s, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#000"/>`, px(xmin), py(0), px(xmin), py(ymax))
+	fmt.Fprintf(&s, `<text x="%.1f" y="%.1f" text-anchor="start">%.1f</text>`, px(xmin), py(0)+16, xmin)
+	fmt.Fprintf(&s, `<text x="%.1f" y="%.1f" text-anchor="end">%.1f</text>`, px(xmax), py(0)+16, xmax)
+	fmt.Fprintf(&s, `<text x="%.1f" y="%.1f" text-anchor="middle">%s</text>`, px((xmin+xmax)/2), chartHeight-10, template.HTMLEscapeString(xlabel))
+	fmt.Fprintf(&s, `<text x="12" y="%.1f" text-anchor="middle" transform="rotate(-90 12 %.1f)">%s</text>`, chartHeight/2, chartHeight/2, template.HTMLEscapeString(ylabel))
+
+	// Reference line
+	if !math.IsNaN(ref) {
+		fmt.Fprintf(&s, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#e08000" stroke-dasharray="4 3"/>`, px(xmin), py(ref), px(xmax), py(ref))
+	}
+
+	// Data
+	if len(xs) > 0 {
+		s.WriteString(`<polyline fill="none" stroke="#2060c0" stroke-width="2" points="`)
+		for i := range xs {
+			fmt.Fprintf(&s, "%.1f,%.1f ", px(xs[i]), py(ys[i]))
+		}
+		s.WriteString(`"/>`)
+		for i := range xs {
+			fmt.Fprintf(&s, `<circle cx="%.1f" cy="%.1f" r="3" fill="#2060c0"><title>%.3f</title></circle>`, px(xs[i]), py(ys[i]), ys[i])
+		}
+	}
+
+	s.WriteString(`</svg>`)
+	return template.HTML(s.String())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteHTML(t *testing.T) {
+	dir := t.TempDir()
+	render := func(r *Report) string {
+		name := filepath.Join(dir, "report.html")
+		if err := r.WriteHTML(name); err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+
+	// The score row has one cell per existing series
+	r := testReport()
+	r.Score = &Score{Single: 1.5, Multi: 2.5}
+	out := render(r)
+	if !strings.Contains(out, `<tr><th class="l">Normalized score</th><td>1.500</td></tr>`) {
+		t.Errorf("unexpected single-thread score row:\n%s", out)
+	}
+	if !strings.Contains(out, "<svg") || strings.Contains(out, "Multi-thread") {
+		t.Errorf("unexpected series:\n%s", out)
+	}
+
+	r.Multi = &ReportSeries{Workers: 8, Throughput: []float64{800.0}}
+	out = render(r)
+	if !strings.Contains(out, `<tr><th class="l">Normalized score</th><td>1.500</td><td>2.500</td></tr>`) {
+		t.Errorf("unexpected score row:\n%s", out)
+	}
+
+	// The score does not depend on the identity of the series
+	r.Single = r.Multi
+	out = render(r)
+	if !strings.Contains(out, `<tr><th class="l">Normalized score</th><td>1.500</td><td>2.500</td></tr>`) {
+		t.Errorf("unexpected score row with shared series:\n%s", out)
+	}
+
+	r.Single = nil
+	out = render(r)
+	if !strings.Contains(out, `<tr><th class="l">Normalized score</th><td>2.500</td></tr>`) {
+		t.Errorf("unexpected multi-thread score row:\n%s", out)
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// ExitInterrupted is the exit code of an interrupted benchmark (like a shell
+// reporting a process killed by SIGINT)
+const ExitInterrupted = 130
+
+// ErrInterrupted is returned when the benchmark has been interrupted by a signal
+var ErrInterrupted = errors.New("interrupted")
+
+// State of the interruption. The first signal stops the benchmark cleanly, and
+// is forwarded to the running child process. A second signal exits immediately.
+var (
+	interruptOnce sync.Once
+	interruptCh   = make(chan struct{})
+	childProcess  atomic.Pointer[os.Process]
+)
+
+// handleInterrupts installs the handler of SIGINT, SIGTERM and SIGHUP (the
+// terminal is closed), which are forwarded to the running child process
+func handleInterrupts() {
+	ch := make(chan os.Signal, 2)
+	signal.Notify(ch, os.Interrupt, syscall.SIGTERM, syscall.SIGHUP)
+	go func() {
+		for sig := range ch {
+			if interrupted() {
+				log.Printf("Interrupted again (%v): exit", sig)
+				if p := childProcess.Load(); p != nil {
+					p.Kill()
+				}
+				os.Exit(ExitInterrupted)
+			}
+			log.Printf("Interrupted (%v): stopping the current iteration", sig)
+			interruptOnce.Do(func() { close(interruptCh) })
+			if p := childProcess.Load(); p != nil {
+				forwardSignal(p, sig)
+			}
+		}
+	}()
+}
+
+// interrupted returns true once a signal has been received
+func interrupted() bool {
+	select {
+	case <-interruptCh:
+		return true
+	default:
+		return false
+	}
+}
+
+// sleepInterruptible waits for a duration, and returns false if it has been interrupted
+func sleepInterruptible(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-interruptCh:
+		return false
+	}
+}
+
+// interruptContext returns a context cancelled by the first signal
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-interruptCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
+
+// forwardSignal sends the signal to a child process. Signals cannot be sent
+// on all platforms (e.g. Windows): the child is then killed.
+func forwardSignal(p *os.Process, sig os.Signal) {
+	if err := p.Signal(sig); err != nil {
+		p.Kill()
+	}
+}
+
+// displayPartial warns that only the completed iterations are displayed
+func displayPartial(completed int) {
+	log.Printf("Benchmark interrupted: PARTIAL results (%d completed iterations)", completed)
+	log.Print()
+}
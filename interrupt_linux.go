@@ -0,0 +1,17 @@
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachChild starts a child process in its own process group. A Ctrl-C in a
+// terminal is sent to the foreground process group: only the parent receives
+// it, and forwards it once to the child, which can stop cleanly. The hangup
+// of the terminal is forwarded the same way, and the child is killed if the
+// parent dies without forwarding anything (e.g. SIGKILL). The parent death
+// signal is sent when the thread which has started the child exits, so the
+// caller must lock its goroutine to its thread until the child exits.
+func detachChild(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestDetachChildHelper is the parent process of TestDetachChildParentDeath:
+// it starts a detached child, displays its pid, and waits to be killed
+func TestDetachChildHelper(t *testing.T) {
+	if os.Getenv("CPUBENCH1A_DETACH_HELPER") == "" {
+		t.Skip("helper process")
+	}
+	cmd := exec.Command("sleep", "60")
+	detachChild(cmd)
+	runtime.LockOSThread()
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	fmt.Println(cmd.Process.Pid)
+	cmd.Wait()
+}
+
+func TestDetachChildParentDeath(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip(err)
+	}
+	parent := exec.Command(os.Args[0], "-test.run=^TestDetachChildHelper$")
+	parent.Env = append(os.Environ(), "CPUBENCH1A_DETACH_HELPER=1")
+	out, err := parent.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parent.Start(); err != nil {
+		t.Fatal(err)
+	}
+	line, err := bufio.NewReader(out).ReadString('\n')
+	pid, _ := strconv.Atoi(strings.TrimSpace(line))
+	if err != nil || pid <= 0 {
+		parent.Process.Kill()
+		t.Fatalf("no child pid: %q, %v", line, err)
+	}
+
+	// The child must not outlive a parent killed without forwarding any signal
+	parent.Process.Signal(syscall.SIGKILL)
+	parent.Wait()
+	deadline := time.Now().Add(10 * time.Second)
+	for processAlive(pid) {
+		if time.Now().After(deadline) {
+			syscall.Kill(pid, syscall.SIGKILL)
+			t.Fatalf("child %d still running after the death of its parent", pid)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// processAlive returns true if a process exists and is not a zombie
+func processAlive(pid int) bool {
+	b, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return false
+	}
+	f := strings.Fields(string(b[strings.LastIndexByte(string(b), ')')+1:]))
+	return len(f) > 0 && f[0] != "Z"
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package main
+
+import "os/exec"
+
+// detachChild does nothing on this platform: the signals cannot be forwarded,
+// and the child is killed on interruption (see forwardSignal)
+func detachChild(cmd *exec.Cmd) {}
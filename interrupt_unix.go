@@ -0,0 +1,17 @@
+//go:build unix && !linux
+
+package main
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detachChild starts a child process in its own process group. A Ctrl-C in a
+// terminal is sent to the foreground process group: only the parent receives
+// it, and forwards it once to the child, which can stop cleanly. The hangup
+// of the terminal is forwarded the same way. There is no parent death signal
+// on this platform: a child outlives a parent killed with SIGKILL.
+func detachChild(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
@@ -0,0 +1,105 @@
+//go:build unix
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestInterruptTerminal simulates a Ctrl-C in a terminal, which sends SIGINT
+// to the whole foreground process group: the running iteration must stop
+// cleanly, and the completed iterations must be kept. It builds and runs the
+// benchmark for several seconds, so it only runs with CPUBENCH1A_E2E=1.
+func TestInterruptTerminal(t *testing.T) {
+	if os.Getenv("CPUBENCH1A_E2E") == "" {
+		t.Skip("end-to-end test: set CPUBENCH1A_E2E=1 to run it")
+	}
+	gobin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go command not available")
+	}
+	dir := t.TempDir()
+	exe := filepath.Join(dir, "cpubench1a")
+	if out, err := exec.Command(gobin, "build", "-o", exe, ".").CombinedOutput(); err != nil {
+		t.Fatalf("build: %v\n%s", err, out)
+	}
+
+	// The benchmark is the leader of its own process group, like a job of a shell
+	resfile, report := filepath.Join(dir, "res.json"), filepath.Join(dir, "report.json")
+	var out bytes.Buffer
+	cmd := exec.Command(exe, "-bench", "-nb", "3", "-duration", "2", "-threads", "1", "-nohist", "-res", resfile, "-json", report)
+	cmd.Stdout, cmd.Stderr = &out, &out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Interrupt the second iteration
+	deadline := time.Now().Add(time.Minute)
+	for {
+		rf, err := LoadResult(resfile)
+		if err == nil && len(rf.Records) > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			cmd.Process.Kill()
+			t.Fatalf("first iteration not completed:\n%s", out.String())
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	time.Sleep(500 * time.Millisecond)
+	if err := syscall.Kill(-cmd.Process.Pid, syscall.SIGINT); err != nil {
+		t.Fatal(err)
+	}
+
+	err = cmd.Wait()
+	var exit *exec.ExitError
+	if !errors.As(err, &exit) || exit.ExitCode() != ExitInterrupted {
+		t.Fatalf("err=%v, expected exit code %d:\n%s", err, ExitInterrupted, out.String())
+	}
+	log := out.String()
+	if strings.Contains(log, "Interrupted again") || !strings.Contains(log, "Iteration interrupted: result not recorded") {
+		t.Errorf("the iteration did not stop cleanly:\n%s", log)
+	}
+
+	// The completed iteration is kept, and the report is partial
+	rf, err := LoadResult(resfile)
+	if err != nil || len(rf.Records) != 1 {
+		t.Fatalf("records=%v, err=%v", rf, err)
+	}
+	b, err := os.ReadFile(report)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r Report
+	if err := json.Unmarshal(b, &r); err != nil || !r.Partial || r.Single == nil || len(r.Single.Throughput) != 1 {
+		t.Errorf("unexpected report (err=%v): %s", err, b)
+	}
+}
+
+func TestDetachChild(t *testing.T) {
+	cmd := exec.Command("sleep", "10")
+	detachChild(cmd)
+	if err := cmd.Start(); err != nil {
+		t.Skip(err)
+	}
+	defer cmd.Process.Kill()
+
+	// The child must not receive the signals sent to the group of the parent
+	pgid, err := syscall.Getpgid(cmd.Process.Pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pgid == syscall.Getpgrp() || pgid != cmd.Process.Pid {
+		t.Errorf("child in process group %d, parent in %d", pgid, syscall.Getpgrp())
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"log"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// displayLatency displays the latency percentiles
+func displayLatency(l *bench.Latency) {
+	log.Print("Latency (ms)       mean        p50        p90        p99      p99.9        max")
+	for _, x := range []struct {
+		name string
+		s    bench.LatencyStat
+	}{{"queue", l.Queue}, {"service", l.Service}, {"total", l.Total}} {
+		log.Printf("    %-8s %10.3f %10.3f %10.3f %10.3f %10.3f %10.3f", x.name, x.s.Mean, x.s.P50, x.s.P90, x.s.P99, x.s.P999, x.s.Max)
+	}
+	log.Print()
+}
+
+// displayOLTP displays the CPU usage and the latency percentiles of the
+// transactions for each OLTP throughput level
+func displayOLTP(points []OLTPPoint) {
+	log.Print("OLTP results (latency in ms)")
+	log.Print("       TPS   Measured  CPU usage        p50        p90        p99      p99.9")
+	for _, pt := range points {
+		if pt.Latency == nil {
+			log.Printf("    %6d %10.3f %10.3f", pt.TPS, pt.Throughput, pt.CPUUsage)
+			continue
+		}
+		l := pt.Latency.Total
+		log.Printf("    %6d %10.3f %10.3f %10.3f %10.3f %10.3f %10.3f", pt.TPS, pt.Throughput, pt.CPUUsage, l.P50, l.P90, l.P99, l.P999)
+	}
+	log.Print()
+}
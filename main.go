@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -12,63 +13,144 @@ import (
 	"strings"
 	"time"
 
+	"github.com/AmadeusITGroup/cpubench1a/bench"
 	"github.com/shirou/gopsutil/v3/cpu"
 )
 
 // Version of the program
-const Version = "5.0"
+const Version = "6.0"
 
 // Definition of the command line flags
 var (
-	flagWorkers  = flag.Int("workers", -1, "Number of workers. Default is 4*threads")
-	flagThreads  = flag.Int("threads", -1, "Number of Go threads (i.e. GOMAXPROCS). Default is all OS processors")
-	flagRun      = flag.Bool("run", false, "Run a single benchmark iteration")
-	flagRunOLTP  = flag.Bool("runoltp", false, "Run a single iteration of the OLTP benchmark")
-	flagBench    = flag.Bool("bench", false, "Run standard benchmark (multiple iterations)")
-	flagFreq     = flag.Bool("freq", false, "Measure the frequency of the CPU")
-	flagOLTP     = flag.Bool("oltp", false, "Run OLTP benchmark (multiple iterations)")
-	flagTPS      = flag.Int("tps", 100, "Target throughput of OLTP benchamrk")
-	flagDuration = flag.Int("duration", 60, "Duration in seconds of a single iteration")
-	flagNb       = flag.Int("nb", 10, "Number of iterations")
-	flagRes      = flag.String("res", "", "Optional result append file")
-	flagVersion  = flag.Bool("version", false, "Display program version and exit")
+	flagWorkers    = flag.Int("workers", -1, "Number of workers. Default is 4*threads (threads with -pin)")
+	flagThreads    = flag.Int("threads", -1, "Number of Go threads (i.e. GOMAXPROCS). Default is all OS processors")
+	flagRun        = flag.Bool("run", false, "Run a single benchmark iteration")
+	flagRunOLTP    = flag.Bool("runoltp", false, "Run a single iteration of the OLTP benchmark")
+	flagBench      = flag.Bool("bench", false, "Run standard benchmark (multiple iterations)")
+	flagFreq       = flag.Bool("freq", false, "Measure the frequency of the CPU")
+	flagOLTP       = flag.Bool("oltp", false, "Run OLTP benchmark (multiple iterations)")
+	flagScaling    = flag.Bool("scaling", false, "Run scaling benchmark (multiple iterations for 1, 2, 4, ... workers up to threads)")
+	flagNuma       = flag.Bool("numa", false, "Run the multi-threaded benchmark on each NUMA node in turn, then on all the nodes")
+	flagCPUSweep   = flag.Bool("cpusweep", false, "Run a short single-threaded benchmark pinned to each logical CPU in turn")
+	flagSMT        = flag.Bool("smt", false, "Measure the SMT (hyper-threading) yield per physical core and overall")
+	flagSweepDur   = flag.Int("sweepduration", 10, "Duration in seconds of the iteration of each CPU with -cpusweep, or each core with -smt")
+	flagScalingAll = flag.Bool("scalingall", false, "With -scaling, run the benchmark for every number of workers up to threads")
+	flagTPS        = flag.Int("tps", 100, "Target throughput of OLTP benchamrk")
+	flagInjector   = flag.String("injector", "ticker", "Injection policy of the OLTP benchmark: ticker (periodic bursts) or poisson (open-loop random arrivals)")
+	flagWarmup     = flag.Int("warmup", 0, "Duration in seconds of the warm-up phase of each iteration (maximum duration with -steady, a quarter of -duration by default)")
+	flagSteady     = flag.Bool("steady", false, "Start the measurement of each iteration once the throughput is stable")
+	flagSteadyCoV  = flag.Float64("steadycov", 5.0, "With -steady, coefficient of variation (in %) of the throughput below which the steady state is reached")
+	flagTimeDrop   = flag.Float64("timelinedrop", 10.0, "Throughput drop (in %) below the median of an iteration reported in the timeline (0 to disable)")
+	flagCPUSet     = flag.String("cpuset", "", "Restrict the process to a list of CPUs such as 0-7,16-23")
+	flagPin        = flag.String("pin", "", "Pin the workers to CPUs: compact, scatter, node, or a list of CPUs such as 0,2,4-7")
+	flagWorkloads  = flag.String("workloads", "", "Custom workload mix: algorithms or categories to include (or exclude with a - prefix), with optional weights such as crypto=2,memory")
+	flagProfile    = flag.String("profile", "", "Custom transaction mix: JSON file defining transaction types as sequences of algorithms, with their probability")
+	flagAwkScript  = flag.String("awkscript", "", "User AWK script run at each transaction, as an additional workload")
+	flagAwkInput   = flag.String("awkinput", "", "Input file of the user AWK script")
+	flagAwkHash    = flag.String("awkhash", "", "Expected SHA-256 hash of the output of the user AWK script. Default is the output of a first run")
+	flagDuration   = flag.Int("duration", 60, "Duration in seconds of a single iteration")
+	flagNb         = flag.Int("nb", 10, "Number of iterations")
+	flagRes        = flag.String("res", "", "Optional result append file")
+	flagJSON       = flag.String("json", "", "Optional JSON report file (with -bench or -oltp)")
+	flagBaseline   = flag.String("baseline", "", "Optional baseline file (reference machine results) to calculate a normalized score. Default is the embedded reference machine")
+	flagHTML       = flag.String("html", "", "Optional HTML report file (with -bench or -oltp)")
+	flagProm       = flag.String("prom", "", "Optional OpenMetrics file (for node_exporter textfile collector)")
+	flagMinSingle  = flag.Float64("minsingle", 0, "Minimum single-threaded throughput to pass the regression gate (0 to disable)")
+	flagMinMulti   = flag.Float64("minmulti", 0, "Minimum multi-threaded throughput to pass the regression gate (0 to disable)")
+	flagMaxDrop    = flag.Float64("maxdrop", 0, "Maximum drop (in %) of throughput below the baseline to pass the regression gate (0 to disable)")
+	flagMaxCoV     = flag.Float64("maxcov", 5.0, "Coefficient of variation (in %) above which results are reported as noisy (0 to disable)")
+	flagVersion    = flag.Bool("version", false, "Display program version and exit")
+	flagCompare    = flag.Bool("compare", false, "Compare two result files given as arguments (reference first)")
+	flagFit        = flag.Bool("fit", false, "Fit the scalability models (USL and Amdahl) on the result files given as arguments")
+	flagHistory    = flag.Bool("history", false, "Display the local history, filtered by host=, cpu=, version= or mode= arguments")
+	flagHistDir    = flag.String("histdir", "", "Directory of the local history. Default is the user configuration directory")
+	flagNoHist     = flag.Bool("nohist", false, "Do not record the run in the local history")
 )
 
 // main entry point of the progam
 func main() {
 
 	flag.Parse()
+	handleInterrupts()
+
+	// Select the algorithms run at each transaction
+	err := selectWorkloads()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if customMix() && !*flagRun && !*flagRunOLTP {
+		log.Printf("WARNING: custom workload mix (%s): results are not comparable with the standard benchmark", mixLabel())
+		log.Print()
+	}
+
+	// Restrict the process to a set of CPUs before the Go runtime starts more threads
+	if *flagCPUSet != "" {
+		if err := restrictCPUs(*flagCPUSet); err != nil {
+			log.Fatal(err)
+		}
+	}
 
 	// Fix number of of threads of the Go runtime.
-	// By default, 4 workers per thread.
+	// By default, 4 workers per thread, or 1 if the workers are pinned: each
+	// pinned worker is locked to its own OS thread.
 	if *flagThreads == -1 {
 		*flagThreads = runtime.NumCPU()
 	}
 	if *flagWorkers == -1 {
 		*flagWorkers = *flagThreads * 4
+		if *flagPin != "" {
+			*flagWorkers = *flagThreads
+		}
+	}
+	if *flagPin != "" && *flagWorkers > *flagThreads {
+		log.Fatalf("With -pin, the number of workers (%d) cannot exceed the number of threads (%d)", *flagWorkers, *flagThreads)
 	}
 	runtime.GOMAXPROCS(*flagThreads)
 
 	// Run a single iteration or a full benchmark
-	var err error
 	switch {
 	case *flagRun:
-		err = runBench(injectSaturation)
+		err = runBench("run", bench.InjectSaturation)
 	case *flagRunOLTP:
-		err = runBench(injectOLTP)
+		var inject bench.Injector
+		if inject, err = oltpInjector(); err == nil {
+			err = runBench("runoltp", inject)
+		}
 	case *flagBench:
 		err = stdBench()
 	case *flagOLTP:
 		err = oltpBench()
+	case *flagScaling:
+		err = scalingBench()
+	case *flagNuma:
+		err = numaBench()
+	case *flagCPUSweep:
+		err = cpuSweep()
+	case *flagSMT:
+		err = smtBench()
 	case *flagFreq:
 		err = measureFreq()
 	case *flagVersion:
 		err = displayVersion()
+	case *flagCompare:
+		err = compareResults(flag.Args())
+	case *flagFit:
+		err = fitResults(flag.Args())
+	case *flagHistory:
+		err = showHistory(flag.Args())
 	default:
 		flag.Usage()
 		os.Exit(-1)
 	}
 
+	// A failed regression gate has a specific exit code (failures are already displayed)
+	var gate *GateError
+	if errors.As(err, &gate) {
+		os.Exit(gate.Code)
+	}
+	if errors.Is(err, ErrInterrupted) {
+		os.Exit(ExitInterrupted)
+	}
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -76,69 +158,60 @@ func main() {
 	os.Exit(0)
 }
 
-// Injector is an injection policy
-type Injector func(chan WorkerOp, chan bool)
-
-// runBench runs a simple benchmark
-func runBench(inject Injector) error {
+// runBench runs a simple benchmark. The mode is recorded with the result.
+func runBench(mode string, inject bench.Injector) error {
 
 	log.Printf("CPU benchmark with %d threads and %d workers", *flagThreads, *flagWorkers)
-
-	// We will maintain the workers busy by pre-filling a buffered channel
-	init := make(chan WorkerOp, *flagWorkers)
-	input := make(chan WorkerOp, *flagWorkers*32)
-	output := make(chan int, *flagWorkers)
-
-	log.Printf("Initializing workers")
-
-	// Spawn workers and trigger initialization
-	workers := []*Worker{}
-	for i := 0; i < *flagWorkers; i++ {
-		w := NewWorker(i, init, input, output)
-		workers = append(workers, w)
-		go w.Run()
-		init <- OpInit
+	if customMix() {
+		log.Printf("Custom workload mix: %s", mixLabel())
 	}
-
-	// Wait for all workers to be initialized
-	for range workers {
-		<-output
+	placement, err := newPinning()
+	if err != nil {
+		return err
 	}
 
-	// Run a synchronous garbage collection now to avoid processing the garbage
-	// associated to the initialization during the benchmark
-	runtime.GC()
-	runtime.GC()
-
-	// Start the benchmark: it will run for a given duration
-	log.Printf("Start")
-	begin := time.Now()
-	stop := make(chan bool)
-	time.AfterFunc(time.Duration(*flagDuration)*time.Second, func() {
-		log.Printf("Stop signal")
-		stop <- true
-	})
+	// Run the iteration, stopped by the first signal
+	ctx, cancel := interruptContext()
+	defer cancel()
+	cfg := newBenchConfig(inject, placement)
+	it, err := bench.RunIteration(ctx, cfg)
+	if it == nil {
+		return err
+	}
 
-	// Apply the injection
-	inject(input, stop)
+	// Display the resulting throughput
+	log.Printf("THROUGHPUT %.6f", it.Throughput)
+	displayBreakdown("Breakdown per algorithm", it.Breakdown)
+	displayTypes("Throughput per transaction type", it.Types)
+	displayLatency(it.Latency)
+	displayTimeline(it.Timeline)
 
-	// Signal the end of the benchmark to workers, and aggregate results
-	for range workers {
-		input <- OpExit
-	}
-	nb := 0
-	for range workers {
-		nb += <-output
+	// An interrupted iteration is incomplete: it is not recorded
+	if err != nil {
+		log.Print("Iteration interrupted: result not recorded")
+		return ErrInterrupted
 	}
-	end := time.Now()
-	log.Printf("End")
-
-	// Calculate resulting throughput
-	ns := float64(end.Sub(begin).Nanoseconds())
-	res := float64(nb) * 1000000000.0 / ns
-	log.Printf("THROUGHPUT %.6f", res)
 	if *flagRes != "" {
-		if err := AppendResult(*flagRes, *flagWorkers, res); err != nil {
+		rec := ResultRecord{
+			Mode:         mode,
+			Workers:      cfg.Workers,
+			Threads:      cfg.Threads,
+			Duration:     int(cfg.Duration.Seconds()),
+			Warmup:       it.Warmup.Seconds(),
+			Start:        it.Start,
+			End:          it.End,
+			Transactions: it.Transactions,
+			Throughput:   it.Throughput,
+			Breakdown:    it.Breakdown,
+			Latency:      it.Latency,
+			Timeline:     it.Timeline,
+			CPUSet:       *flagCPUSet,
+			Pin:          *flagPin,
+			Placement:    placement,
+			Mix:          mixLabel(),
+			Types:        it.Types,
+		}
+		if err := AppendResult(*flagRes, rec); err != nil {
 			log.Print(err)
 			log.Printf("Cannot write result into temporary file: %s", *flagRes)
 		}
@@ -148,74 +221,38 @@ func runBench(inject Injector) error {
 	return nil
 }
 
-// injectSaturation injects traffic by saturating the input queue.
-// It is used for the standard benchmark.
-func injectSaturation(input chan WorkerOp, stop chan bool) {
-
-	// Saturation benchmark loop: we avoid checking for the timeout too often
-	for {
-		select {
-		case <-stop:
-			return
-		default:
-			for i := 0; i < *flagWorkers*16; i++ {
-				input <- OpStep
-			}
-		}
+// newBenchConfig builds the configuration of the benchmark engine from the
+// options of the command line. GOMAXPROCS is already set by main.
+func newBenchConfig(inject bench.Injector, placement Placement) bench.Config {
+	cfg := bench.Config{
+		Threads:      *flagThreads,
+		Workers:      *flagWorkers,
+		Duration:     time.Duration(*flagDuration) * time.Second,
+		Warmup:       time.Duration(*flagWarmup) * time.Second,
+		Steady:       *flagSteady,
+		SteadyCoV:    *flagSteadyCoV,
+		TimelineDrop: *flagTimeDrop,
+		Injector:     inject,
+		TPS:          *flagTPS,
+		Workloads:    workloadMix.Workloads,
+		WorkerStart:  pinWorkers(placement),
+		Logger:       log.Default(),
 	}
+	if profile != nil {
+		cfg.Transactions, cfg.Seed = profile.Types(), profile.RandomSeed()
+	}
+	return cfg
 }
 
-// injectOLTP injects traffic by limiting the input throughput.
-// It is used for the OLTP benchmark.
-func injectOLTP(input chan WorkerOp, stop chan bool) {
-
-	// Calculate a suitable period and number of transactions per period
-	var period int
-	switch {
-	case *flagTPS < 10:
-		period = 1000
-	case *flagTPS < 100:
-		period = 100
-	case *flagTPS < 500:
-		period = 50
-	case *flagTPS < 1000:
-		period = 20
-	default:
-		period = 10
-	}
-	nbPeriods := 1000 / period
-	nbTrans := *flagTPS / nbPeriods
-
-	// Rounding errors need to be corrected, so the first period is adjusted with a bit more transactions
-	nbTransFirst := *flagTPS - nbTrans*nbPeriods
-	if nbTransFirst < 0 {
-		nbTransFirst = 0
-	}
-
-	log.Printf("Injection: %d transactions every %d ms for %d periods/s", nbTrans, period, nbPeriods)
-	log.Printf("Injection correction: %d", nbTransFirst)
-	ticker := time.Tick(time.Duration(period) * time.Millisecond)
-	iPeriod := 0
-
-	// Inject nbTrans transactions for each period
-	for {
-		select {
-		case <-stop:
-			return
-		case <-ticker:
-			n := nbTrans
-			if iPeriod == 0 {
-				n += nbTransFirst
-			}
-			for i := 0; i < n; i++ {
-				input <- OpStep
-			}
-			iPeriod++
-			if iPeriod == nbPeriods {
-				iPeriod = 0
-			}
-		}
+// oltpInjector returns the injection policy of the OLTP benchmark
+func oltpInjector() (bench.Injector, error) {
+	switch *flagInjector {
+	case "ticker":
+		return bench.InjectTicker, nil
+	case "poisson":
+		return bench.InjectPoisson, nil
 	}
+	return nil, fmt.Errorf("unknown injector: %s", *flagInjector)
 }
 
 // stdBench runs multiple benchmarks (single-threaded and then multi-threaded)
@@ -224,50 +261,111 @@ func stdBench() error {
 	// Display CPU information
 	log.Println("Version: ", Version)
 	log.Print()
-	if err := displayCPU(); err != nil {
+	cpuinfo, err := displayCPU()
+	if err != nil {
 		return nil
 	}
+	report := NewReport("bench", cpuinfo)
 
-	// Create a file storing the results
-	var resFile *os.File
-	var err error
-	if *flagRes != "" {
-		resFile, err = os.Create(*flagRes)
-		if err != nil {
-			return err
-		}
-	} else {
-		resFile, err = os.CreateTemp("", "cpubench1a-*")
-		if err != nil {
+	// Load the reference scores before running anything, so an incompatible baseline is reported early.
+	// Without -baseline, the embedded reference machine is used, unless the mix is custom.
+	var base *Baseline
+	if *flagBaseline != "" && customMix() {
+		return errors.New("a baseline cannot be used with a custom workload mix")
+	}
+	if *flagBaseline != "" || !customMix() {
+		if base, err = LoadBaseline(*flagBaseline); err != nil {
 			return err
 		}
-		defer os.Remove(resFile.Name())
+	}
+	if *flagMaxDrop > 0.0 && base == nil {
+		return errors.New("a baseline is required to check the maximum drop of throughput")
 	}
 
+	// Create a file storing the results
+	resFile, err := createResultFile()
+	if err != nil {
+		return err
+	}
+	defer closeResultFile(resFile)
+
 	// Run multiple benchmarks in sequence
 	log.Print("Single threaded performance")
 	log.Print("===========================")
 	log.Print()
-	for i := 0; i < *flagNb; i++ {
+	for i := 0; i < *flagNb && !report.Partial; i++ {
 		if err := spawnBench(1, resFile.Name()); err != nil {
-			return err
+			if report.Partial = errors.Is(err, ErrInterrupted); !report.Partial {
+				return err
+			}
 		}
 	}
 
 	// Run multiple benchmarks in sequence
-	log.Print("Multi-threaded performance")
-	log.Print("==========================")
-	log.Print()
-	for i := 0; i < *flagNb; i++ {
+	if !report.Partial {
+		log.Print("Multi-threaded performance")
+		log.Print("==========================")
+		log.Print()
+	}
+	for i := 0; i < *flagNb && !report.Partial; i++ {
 		if err := spawnBench(*flagWorkers, resFile.Name()); err != nil {
-			return err
+			if report.Partial = errors.Is(err, ErrInterrupted); !report.Partial {
+				return err
+			}
 		}
 	}
 
-	// Display statistics from the temporary file
-	DisplayResult(resFile, *flagWorkers)
-	resFile.Close()
-	return nil
+	// Display statistics from the temporary file. After an interruption, the
+	// completed iterations are displayed, but there is no score.
+	rf, err := readResultFile(resFile)
+	if err != nil {
+		return err
+	}
+	if report.Partial {
+		displayPartial(len(rf.Records))
+		base = nil
+	}
+	m := rf.Map()
+	DisplayResult(m, *flagWorkers, base)
+	checkNoise("Single thread", m[1], *flagMaxCoV)
+	checkNoise("Multi-thread", m[*flagWorkers], *flagMaxCoV)
+	displayBreakdown("Single thread breakdown per algorithm", averageBreakdown(rf.Select(1)))
+	displayBreakdown("Multi-thread breakdown per algorithm", averageBreakdown(rf.Select(*flagWorkers)))
+	displayTypes("Single thread throughput per transaction type", averageTypes(rf.Select(1)))
+	displayTypes("Multi-thread throughput per transaction type", averageTypes(rf.Select(*flagWorkers)))
+
+	// Build the reports
+	report.Single = NewReportSeries(rf, 1)
+	report.Multi = NewReportSeries(rf, *flagWorkers)
+	if base != nil {
+		report.Score = NewScore(base, m, *flagWorkers)
+	}
+	if err := writeReports(report); err != nil {
+		return err
+	}
+	if report.Partial {
+		return ErrInterrupted
+	}
+
+	// Evaluate the qualification thresholds once all the results are saved
+	return checkGate(m, *flagWorkers, base)
+}
+
+// createResultFile creates the file storing the results of the iterations.
+// It is a temporary file unless the user has requested to keep it.
+func createResultFile() (*os.File, error) {
+	if *flagRes != "" {
+		return os.Create(*flagRes)
+	}
+	return os.CreateTemp("", "cpubench1a-*")
+}
+
+// closeResultFile closes the result file, and removes it if it is temporary
+func closeResultFile(f *os.File) {
+	f.Close()
+	if *flagRes == "" {
+		os.Remove(f.Name())
+	}
 }
 
 // oltpBench runs multiple OLTP benchmark progressively increasing the throughput.
@@ -277,9 +375,18 @@ func oltpBench() error {
 	// Display CPU information
 	log.Println("Version: ", Version)
 	log.Print()
-	if err := displayCPU(); err != nil {
+	cpuinfo, err := displayCPU()
+	if err != nil {
 		return nil
 	}
+	report := NewReport("oltp", cpuinfo)
+
+	// Create a file storing the measured throughput of each iteration
+	resFile, err := createResultFile()
+	if err != nil {
+		return err
+	}
+	defer closeResultFile(resFile)
 
 	log.Print("OLTP benchmark")
 	log.Print("==============")
@@ -292,17 +399,20 @@ func oltpBench() error {
 	}
 
 	// We run a few more iterations to try to saturate the CPU
+	var usage []float64
 	for i := 0; i < *flagNb+4; i++ {
 
 		if i == 0 {
 			// Used to measure CPU usage when nothing runs (zero throughput)
-			time.Sleep(time.Duration(*flagDuration) * time.Second)
-		} else {
-			// No temp file to fetch results for now
-			if err := spawnOLTP(i); err != nil {
+			report.Partial = !sleepInterruptible(time.Duration(*flagDuration) * time.Second)
+		} else if err := spawnOLTP(i, resFile.Name()); err != nil {
+			if report.Partial = errors.Is(err, ErrInterrupted); !report.Partial {
 				return err
 			}
 		}
+		if report.Partial {
+			break
+		}
 
 		// This represents the average CPU usage percentage for the last iteration
 		p, err := cpu.Percent(0, false)
@@ -311,105 +421,179 @@ func oltpBench() error {
 		}
 		log.Printf("CPU USAGE: %.3f", p[0])
 		log.Print()
+		usage = append(usage, p[0])
 	}
 
+	// Build the reports associating the measured throughput to the CPU usage
+	rf, err := readResultFile(resFile)
+	if err != nil {
+		return err
+	}
+	if report.Partial {
+		displayPartial(len(rf.Records))
+	}
+	for i, p := range usage {
+		pt := OLTPPoint{TPS: i * (*flagTPS) / (*flagNb), CPUUsage: p}
+		if i > 0 && i <= len(rf.Records) {
+			pt.Throughput = rf.Records[i-1].Throughput
+			pt.Latency = rf.Records[i-1].Latency
+		}
+		report.OLTP = append(report.OLTP, pt)
+	}
+	displayOLTP(report.OLTP)
+	if err := writeReports(report); err != nil {
+		return err
+	}
+	if report.Partial {
+		return ErrInterrupted
+	}
 	return nil
 }
 
 // spawnBench runs a benchmark as an external process
 func spawnBench(workers int, resfile string) error {
-
-	// Get executable path
-	executable, err := os.Executable()
-	if err != nil {
-		return nil
-	}
-
-	// Build parameters
-	opt := []string{
+	return spawnIteration(
 		"-run",
 		"-threads", strconv.Itoa(*flagThreads),
 		"-workers", strconv.Itoa(workers),
 		"-duration", strconv.Itoa(*flagDuration),
 		"-res", resfile,
-	}
+	)
+}
 
-	// Execute command in blocking mode
-	cmd := exec.Command(executable, opt...)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		return err
+// spawnCPUSet runs a benchmark as an external process restricted to a list of CPUs
+// (all the CPUs if the list is empty)
+func spawnCPUSet(cpus string, threads, workers, duration int, resfile string) error {
+	opt := []string{
+		"-run",
+		"-threads", strconv.Itoa(threads),
+		"-workers", strconv.Itoa(workers),
+		"-duration", strconv.Itoa(duration),
+		"-res", resfile,
+	}
+	if cpus != "" {
+		opt = append(opt, "-cpuset", cpus)
 	}
+	return spawnIteration(opt...)
+}
 
-	return nil
+// iterationArgs returns the options of the iterations to pass to the benchmark child processes
+func iterationArgs() []string {
+	res := []string{
+		"-warmup", strconv.Itoa(*flagWarmup),
+		"-timelinedrop", strconv.FormatFloat(*flagTimeDrop, 'f', -1, 64),
+	}
+	if *flagSteady {
+		res = append(res, "-steady", "-steadycov", strconv.FormatFloat(*flagSteadyCoV, 'f', -1, 64))
+	}
+	if *flagPin != "" {
+		res = append(res, "-pin", *flagPin)
+	}
+	if *flagWorkloads != "" {
+		res = append(res, "-workloads", *flagWorkloads)
+	}
+	if *flagAwkScript != "" {
+		res = append(res, "-awkscript", *flagAwkScript, "-awkinput", *flagAwkInput, "-awkhash", *flagAwkHash)
+	}
+	if *flagProfile != "" {
+		res = append(res, "-profile", *flagProfile)
+	}
+	return res
 }
 
 // spawnOltp runs an OLTP benchmark as an external process
-func spawnOLTP(it int) error {
+func spawnOLTP(it int, resfile string) error {
+	return spawnIteration(
+		"-runoltp",
+		"-tps", strconv.Itoa(it*(*flagTPS)/(*flagNb)),
+		"-threads", strconv.Itoa(*flagThreads),
+		"-workers", strconv.Itoa(*flagWorkers),
+		"-duration", strconv.Itoa(*flagDuration),
+		"-injector", *flagInjector,
+		"-res", resfile,
+	)
+}
+
+// spawnIteration runs a benchmark iteration as an external process (the
+// current executable), with the given options and the options of the iterations
+func spawnIteration(opt ...string) error {
 
 	// Get executable path
 	executable, err := os.Executable()
 	if err != nil {
-		return nil
+		return err
 	}
 
-	// Build parameters
-	opt := []string{
-		"-runoltp",
-		"-tps", strconv.Itoa(it * (*flagTPS) / (*flagNb)),
-		"-threads", strconv.Itoa(*flagThreads),
-		"-workers", strconv.Itoa(*flagWorkers),
-		"-duration", strconv.Itoa(*flagDuration),
+	// Execute command in blocking mode. The child is registered so that the
+	// interruption signals are forwarded to it, and it does not receive the
+	// signals of the terminal directly.
+	if interrupted() {
+		return ErrInterrupted
 	}
-
-	// Execute command in blocking mode
-	cmd := exec.Command(executable, opt...)
+	cmd := exec.Command(executable, append(opt, iterationArgs()...)...)
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
+	detachChild(cmd)
+
+	// The child is started and waited for on the same thread (see detachChild)
+	runtime.LockOSThread()
+	defer runtime.UnlockOSThread()
+	if err := cmd.Start(); err != nil {
 		return err
 	}
-
-	return nil
+	childProcess.Store(cmd.Process)
+	if interrupted() {
+		forwardSignal(cmd.Process, os.Interrupt)
+	}
+	err = cmd.Wait()
+	childProcess.Store(nil)
+	if interrupted() {
+		return ErrInterrupted
+	}
+	return err
 }
 
-// displayCPU displays some CPU information
-func displayCPU() error {
+// displayCPU displays some CPU information, and returns it
+func displayCPU() (CPUInfo, error) {
 
 	ctx := context.Background()
+	var res CPUInfo
 
 	// Get type of CPU, frequency
 	cpuinfo, err := cpu.InfoWithContext(ctx)
 	if err != nil {
-		return err
+		return res, err
 	}
+	res.Vendor = cpuinfo[0].VendorID
+	res.Model = cpuinfo[0].ModelName
+	res.Mhz = cpuinfo[0].Mhz
 
 	var s strings.Builder
-	if cpuinfo[0].VendorID != "" {
-		fmt.Fprintf(&s, "%s / ", cpuinfo[0].VendorID)
+	if res.Vendor != "" {
+		fmt.Fprintf(&s, "%s / ", res.Vendor)
 	}
-	fmt.Fprint(&s, cpuinfo[0].ModelName)
+	fmt.Fprint(&s, res.Model)
 	log.Printf("CPU: %s", s.String())
-	log.Printf("Max freq: %.2f mhz (as reported by OS)", cpuinfo[0].Mhz)
+	log.Printf("Max freq: %.2f mhz (as reported by OS)", res.Mhz)
 
 	// The core/thread count is wrong on some architectures
-	nc, err := cpu.CountsWithContext(ctx, false)
+	res.Cores, err = cpu.CountsWithContext(ctx, false)
 	if err != nil {
-		return err
+		return res, err
 	}
-	nt, err := cpu.CountsWithContext(ctx, true)
+	res.Threads, err = cpu.CountsWithContext(ctx, true)
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	log.Printf("Cores: %d", nc)
-	log.Printf("Threads: %d", nt)
+	log.Printf("Cores: %d", res.Cores)
+	log.Printf("Threads: %d", res.Threads)
 
 	// Display NUMA topology using platform-specific detection
 	DisplayNumaTopology(cpuinfo)
 	log.Print()
-	return nil
+	res.Numa = GetNumaCPUs(cpuinfo)
+	return res, nil
 }
 
 // measureFreq attempts to measure the CPU frequency by counting CPU cycles
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// benchPoint is a configuration run by a benchmark mode: the iterations are
+// restricted to a list of CPUs (all the CPUs if empty), with a given number of
+// threads and workers, and a given duration (in seconds)
+type benchPoint struct {
+	CPUs       string
+	Threads    int
+	Workers    int
+	Duration   int
+	Iterations int
+}
+
+// benchPhase is a titled sequence of points of a benchmark mode
+type benchPhase struct {
+	Title  string
+	Points []benchPoint
+}
+
+// startMode displays the version and the CPU information, and creates the
+// report of a benchmark mode
+func startMode(mode string) (*Report, error) {
+	log.Println("Version: ", Version)
+	log.Print()
+	cpuinfo, err := displayCPU()
+	if err != nil {
+		return nil, err
+	}
+	return NewReport(mode, cpuinfo), nil
+}
+
+// runPhases runs the iterations of the points of each phase as external
+// processes, and returns their results. After an interruption, the report is
+// marked as partial, and the results of the completed iterations are returned.
+func runPhases(report *Report, phases []benchPhase) (*ResultFile, error) {
+
+	// Create a file storing the results
+	resFile, err := createResultFile()
+	if err != nil {
+		return nil, err
+	}
+	defer closeResultFile(resFile)
+
+run:
+	for _, ph := range phases {
+		log.Print(ph.Title)
+		log.Print(strings.Repeat("=", len(ph.Title)))
+		log.Print()
+		for _, pt := range ph.Points {
+			for range pt.Iterations {
+				if err := spawnCPUSet(pt.CPUs, pt.Threads, pt.Workers, pt.Duration, resFile.Name()); err != nil {
+					if report.Partial = errors.Is(err, ErrInterrupted); !report.Partial {
+						return nil, err
+					}
+					break run
+				}
+			}
+		}
+	}
+
+	// Read the results from the temporary file
+	rf, err := readResultFile(resFile)
+	if err == nil && report.Partial {
+		displayPartial(len(rf.Records))
+	}
+	return rf, err
+}
+
+// endMode writes the reports of a benchmark mode. ErrInterrupted is returned
+// if the report is partial.
+func endMode(report *Report) error {
+	if err := writeReports(report); err != nil {
+		return err
+	}
+	if report.Partial {
+		return ErrInterrupted
+	}
+	return nil
+}
+
+// PointStat calculates the statistics of the iterations of a point, identified
+// by its list of CPUs and its number of workers. Like in the standard
+// benchmark, the throughput of a point is the maximum of its iterations.
+func (rf *ResultFile) PointStat(cpus string, workers int) bench.Stat {
+	var r []float64
+	for _, rec := range rf.Records {
+		if rec.CPUSet == cpus && rec.Workers == workers {
+			r = append(r, rec.Throughput)
+		}
+	}
+	return bench.ComputeStat(r)
+}
@@ -228,3 +228,32 @@ func DisplayNumaTopology(cpuinfo []cpu.InfoStat) {
 		log.Printf("  Node %d: %d CPUs (Group %d, Mask 0x%x)", node.NodeNumber, node.CPUCount, node.GroupMask.Group, node.GroupMask.Mask)
 	}
 }
+
+// GetNumaCPUs returns the NUMA node of each CPU
+func GetNumaCPUs(cpuinfo []cpu.InfoStat) []NumaCPU {
+
+	// Get topology information
+	nodes, err := GetNumaTopology()
+	if err != nil || len(nodes) == 0 {
+		return nil
+	}
+
+	// Index the CPU information by logical processor number
+	info := map[int]cpu.InfoStat{}
+	for _, c := range cpuinfo {
+		info[int(c.CPU)] = c
+	}
+
+	// Each bit of the group mask is a logical processor of the group
+	var res []NumaCPU
+	for _, node := range nodes {
+		for bit := 0; bit < 64; bit++ {
+			if node.GroupMask.Mask&(1<<uint(bit)) == 0 {
+				continue
+			}
+			c := int(node.GroupMask.Group)*64 + bit
+			res = append(res, NumaCPU{CPU: c, Socket: info[c].PhysicalID, CoreID: info[c].CoreID, Node: int(node.NodeNumber)})
+		}
+	}
+	return res
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"slices"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// NumaPoint is the multi-threaded throughput of the benchmark confined to the
+// CPUs of a NUMA node (Node is -1 for all the nodes)
+type NumaPoint struct {
+	Node       int        `json:"node"`
+	CPUs       string     `json:"cpus"`
+	Threads    int        `json:"threads"`
+	Workers    int        `json:"workers"`
+	Throughput float64    `json:"throughput"`
+	Stat       bench.Stat `json:"stat"`
+}
+
+// NumaResult is the result of the NUMA benchmark. The efficiency is the ratio
+// between the throughput on all the nodes and the sum of the throughputs of
+// each node: the difference is lost when the workload spans the nodes.
+type NumaResult struct {
+	Nodes      []NumaPoint `json:"nodes"`
+	All        NumaPoint   `json:"all"`
+	Sum        float64     `json:"sum"`
+	Efficiency float64     `json:"efficiency"`
+}
+
+// numaBench runs the multi-threaded benchmark confined to each NUMA node in
+// turn, then across all the nodes
+func numaBench() error {
+
+	report, err := startMode("numa")
+	if err != nil {
+		return err
+	}
+	if !affinitySupported {
+		return fmt.Errorf("the NUMA benchmark is not supported on %s", runtime.GOOS)
+	}
+
+	// Group the CPUs by node
+	nodes := map[int][]int{}
+	for _, c := range report.CPU.Numa {
+		nodes[c.Node] = append(nodes[c.Node], c.CPU)
+	}
+	if len(nodes) == 0 {
+		return errors.New("the NUMA topology is not available")
+	}
+	if len(nodes) == 1 {
+		log.Print("Warning: single NUMA node, spanning nodes cannot be measured")
+		log.Print()
+	}
+
+	// The number of workers per thread is the same as for the whole machine
+	perThread := max(*flagWorkers / *flagThreads, 1)
+	var points []NumaPoint
+	for _, n := range sortedKeys(nodes) {
+		cpus := formatCPUList(slices.Sorted(slices.Values(nodes[n])))
+		points = append(points, NumaPoint{Node: n, CPUs: cpus, Threads: len(nodes[n])})
+	}
+	points = append(points, NumaPoint{Node: -1, Threads: *flagThreads})
+
+	// Run multiple benchmarks in sequence for each node, then for all nodes
+	var phases []benchPhase
+	for i := range points {
+		pt := &points[i]
+		pt.Workers = pt.Threads * perThread
+		title := fmt.Sprintf("Multi-threaded performance on node %d (CPUs %s)", pt.Node, pt.CPUs)
+		if pt.Node < 0 {
+			title = "Multi-threaded performance on all nodes"
+		}
+		phases = append(phases, benchPhase{
+			Title:  title,
+			Points: []benchPoint{{CPUs: pt.CPUs, Threads: pt.Threads, Workers: pt.Workers, Duration: *flagDuration, Iterations: *flagNb}},
+		})
+	}
+	rf, err := runPhases(report, phases)
+	if err != nil {
+		return err
+	}
+	report.NUMA = NewNumaResult(rf, points)
+	displayNuma(report.NUMA)
+	return endMode(report)
+}
+
+// NewNumaResult calculates the throughput of each node, and the efficiency
+// of the benchmark spanning all the nodes. The iterations are associated to
+// the points by CPU set.
+func NewNumaResult(rf *ResultFile, points []NumaPoint) *NumaResult {
+
+	res := &NumaResult{}
+	for _, pt := range points {
+		pt.Stat = rf.PointStat(pt.CPUs, pt.Workers)
+		pt.Throughput = pt.Stat.Max
+		if pt.Node < 0 {
+			res.All = pt
+			continue
+		}
+		res.Nodes = append(res.Nodes, pt)
+		res.Sum += pt.Throughput
+	}
+	if res.Sum > 0.0 {
+		res.Efficiency = res.All.Throughput / res.Sum
+	}
+	return res
+}
+
+// displayNuma displays the throughput of each node, and the efficiency of the
+// benchmark spanning all the nodes
+func displayNuma(r *NumaResult) {
+	log.Print("NUMA results")
+	log.Print("============")
+	log.Print()
+	log.Print("       Node  Threads  Workers     Throughput  CPUs")
+	for _, pt := range r.Nodes {
+		log.Printf("    %7d %8d %8d %14.6f  %s", pt.Node, pt.Threads, pt.Workers, pt.Throughput, pt.CPUs)
+	}
+	log.Printf("    %7s %8d %8d %14.6f", "all", r.All.Threads, r.All.Workers, r.All.Throughput)
+	log.Print()
+	log.Printf("Sum of nodes: %.6f", r.Sum)
+	log.Printf("Spanning efficiency: %.1f%% (%.1f%% lost when spanning nodes)", 100.0*r.Efficiency, 100.0*(1.0-r.Efficiency))
+	log.Print()
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNewNumaResult(t *testing.T) {
+	rf := &ResultFile{Records: []ResultRecord{
+		{Workers: 16, CPUSet: "0-3", Throughput: 100},
+		{Workers: 16, CPUSet: "0-3", Throughput: 110},
+		{Workers: 16, CPUSet: "4-7", Throughput: 90},
+		{Workers: 32, Throughput: 180},
+		{Workers: 32, Throughput: 170},
+	}}
+	points := []NumaPoint{
+		{Node: 0, CPUs: "0-3", Threads: 4, Workers: 16},
+		{Node: 1, CPUs: "4-7", Threads: 4, Workers: 16},
+		{Node: -1, Threads: 8, Workers: 32},
+	}
+	res := NewNumaResult(rf, points)
+	if len(res.Nodes) != 2 || res.Nodes[0].Throughput != 110 || res.Nodes[1].Throughput != 90 || res.All.Throughput != 180 {
+		t.Fatalf("unexpected result: %+v", res)
+	}
+	if res.Sum != 200 || math.Abs(res.Efficiency-0.9) > 1e-9 {
+		t.Errorf("sum=%v efficiency=%v", res.Sum, res.Efficiency)
+	}
+}
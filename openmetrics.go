@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// metricLabels is an ordered list of label names and values
+type metricLabels []string
+
+// WriteOpenMetrics stores the report as OpenMetrics gauges, which can be
+// collected by the textfile collector of node_exporter. The run itself is
+// described by the labels of the cpubench1a_info gauge (always 1): the info
+// type is not accepted by the text parser of node_exporter. The file is atomically replaced, so a scraper never reads a
+// partial file.
+func (r *Report) WriteOpenMetrics(filename string) error {
+
+	var b bytes.Buffer
+	common := metricLabels{"version", r.Version, "cpu_model", r.CPU.Model}
+
+	writeMetricHeader(&b, "cpubench1a_info", "gauge", "Information about the benchmark run")
+	writeMetric(&b, "cpubench1a_info", append(metricLabels{"mode", r.Mode, "host", r.Host, "partial", strconv.FormatBool(r.Partial), "custom_mix", strconv.FormatBool(r.Custom)}, common...), 1)
+	writeMetricHeader(&b, "cpubench1a_end_timestamp_seconds", "gauge", "End time of the benchmark run")
+	writeMetric(&b, "cpubench1a_end_timestamp_seconds", common, float64(r.End.UnixNano())/1.0e9)
+
+	// Throughput of each iteration, and the resulting statistics
+	series := []struct {
+		mode string
+		s    *ReportSeries
+	}{
+		{"single", r.Single},
+		{"multi", r.Multi},
+	}
+	if r.Single != nil || r.Multi != nil {
+		writeMetricHeader(&b, "cpubench1a_throughput", "gauge", "Throughput (transactions/s) of each benchmark iteration")
+		for _, x := range series {
+			if x.s == nil {
+				continue
+			}
+			for i, rec := range x.s.Iterations {
+				l := metricLabels{"mode", x.mode, "threads", strconv.Itoa(rec.Threads), "workers", strconv.Itoa(rec.Workers), "iteration", strconv.Itoa(i + 1)}
+				writeMetric(&b, "cpubench1a_throughput", append(l, common...), rec.Throughput)
+			}
+		}
+		writeMetricHeader(&b, "cpubench1a_throughput_stat", "gauge", "Statistics on the throughput (transactions/s) of the benchmark iterations")
+		for _, x := range series {
+			if x.s == nil {
+				continue
+			}
+			stats := []struct {
+				name  string
+				value float64
+			}{
+				{"min", x.s.Stat.Min},
+				{"average", x.s.Stat.Average},
+				{"median", x.s.Stat.Median},
+				{"geo_mean", x.s.Stat.GeoMean},
+				{"max", x.s.Stat.Max},
+				{"std_dev", x.s.Stat.StdDev},
+			}
+			for _, st := range stats {
+				l := metricLabels{"mode", x.mode, "threads", r.Flags["threads"], "workers", strconv.Itoa(x.s.Workers), "stat", st.name}
+				writeMetric(&b, "cpubench1a_throughput_stat", append(l, common...), st.value)
+			}
+		}
+	}
+
+	// Normalized score
+	if r.Score != nil {
+		writeMetricHeader(&b, "cpubench1a_score", "gauge", "Throughput normalized against the reference machine")
+		writeMetric(&b, "cpubench1a_score", append(metricLabels{"mode", "single"}, common...), r.Score.Single)
+		writeMetric(&b, "cpubench1a_score", append(metricLabels{"mode", "multi"}, common...), r.Score.Multi)
+	}
+
+	// Throughput and CPU usage of each OLTP step
+	if len(r.OLTP) > 0 {
+		oltp := []struct {
+			name  string
+			help  string
+			value func(OLTPPoint) float64
+		}{
+			{"cpubench1a_oltp_throughput", "Measured throughput (transactions/s) of each OLTP step", func(pt OLTPPoint) float64 { return pt.Throughput }},
+			{"cpubench1a_oltp_cpu_usage", "CPU usage (percent) of each OLTP step", func(pt OLTPPoint) float64 { return pt.CPUUsage }},
+		}
+		for _, x := range oltp {
+			writeMetricHeader(&b, x.name, "gauge", x.help)
+			for _, pt := range r.OLTP {
+				l := metricLabels{"mode", "oltp", "threads", r.Flags["threads"], "workers", r.Flags["workers"], "tps", strconv.Itoa(pt.TPS)}
+				writeMetric(&b, x.name, append(l, common...), x.value(pt))
+			}
+		}
+	}
+	// Throughput, speedup and efficiency of each scaling point
+	if len(r.Scaling) > 0 {
+		scaling := []struct {
+			name  string
+			help  string
+			value func(ScalingPoint) float64
+		}{
+			{"cpubench1a_scaling_throughput", "Maximum throughput (transactions/s) for a given number of workers", func(pt ScalingPoint) float64 { return pt.Throughput }},
+			{"cpubench1a_scaling_speedup", "Speedup relative to a single worker", func(pt ScalingPoint) float64 { return pt.Speedup }},
+			{"cpubench1a_scaling_efficiency", "Parallel efficiency (speedup divided by the number of workers)", func(pt ScalingPoint) float64 { return pt.Efficiency }},
+		}
+		for _, x := range scaling {
+			writeMetricHeader(&b, x.name, "gauge", x.help)
+			for _, pt := range r.Scaling {
+				l := metricLabels{"mode", "scaling", "threads", r.Flags["threads"], "workers", strconv.Itoa(pt.Workers)}
+				writeMetric(&b, x.name, append(l, common...), x.value(pt))
+			}
+		}
+	}
+	// Coefficients of the fitted scalability models
+	if len(r.Fits) > 0 {
+		fits := []struct {
+			name  string
+			help  string
+			value func(*ScalabilityFit) float64
+		}{
+			{"cpubench1a_scalability_lambda", "Single worker throughput of the fitted scalability model", func(f *ScalabilityFit) float64 { return f.Lambda }},
+			{"cpubench1a_scalability_contention", "Contention coefficient (sigma) of the fitted scalability model", func(f *ScalabilityFit) float64 { return f.Sigma }},
+			{"cpubench1a_scalability_coherency", "Coherency coefficient (kappa) of the fitted scalability model", func(f *ScalabilityFit) float64 { return f.Kappa }},
+			{"cpubench1a_scalability_r2", "Coefficient of determination of the fitted scalability model", func(f *ScalabilityFit) float64 { return f.R2 }},
+		}
+		for _, x := range fits {
+			writeMetricHeader(&b, x.name, "gauge", x.help)
+			for _, f := range r.Fits {
+				l := metricLabels{"mode", "scaling", "threads", r.Flags["threads"], "model", f.Model}
+				writeMetric(&b, x.name, append(l, common...), x.value(f))
+			}
+		}
+	}
+	// Throughput of each NUMA node, and efficiency when spanning the nodes
+	if r.NUMA != nil {
+		writeMetricHeader(&b, "cpubench1a_numa_throughput", "gauge", "Maximum multi-threaded throughput (transactions/s) confined to a NUMA node")
+		for _, pt := range append(r.NUMA.Nodes, r.NUMA.All) {
+			node := strconv.Itoa(pt.Node)
+			if pt.Node < 0 {
+				node = "all"
+			}
+			l := metricLabels{"mode", "numa", "threads", strconv.Itoa(pt.Threads), "workers", strconv.Itoa(pt.Workers), "node", node}
+			writeMetric(&b, "cpubench1a_numa_throughput", append(l, common...), pt.Throughput)
+		}
+		writeMetricHeader(&b, "cpubench1a_numa_efficiency", "gauge", "Throughput on all the nodes divided by the sum of the throughputs of each node")
+		writeMetric(&b, "cpubench1a_numa_efficiency", append(metricLabels{"mode", "numa"}, common...), r.NUMA.Efficiency)
+	}
+	// Single-threaded throughput of each CPU
+	if len(r.Sweep) > 0 {
+		writeMetricHeader(&b, "cpubench1a_cpu_throughput", "gauge", "Single-threaded throughput (transactions/s) pinned to a logical CPU")
+		for _, pt := range r.Sweep {
+			l := metricLabels{"mode", "cpusweep", "cpu", strconv.Itoa(pt.CPU), "socket", pt.Socket, "core_id", pt.CoreID, "node", strconv.Itoa(pt.Node), "class", strconv.Itoa(pt.Class)}
+			writeMetric(&b, "cpubench1a_cpu_throughput", append(l, common...), pt.Throughput)
+		}
+	}
+	// SMT yield per core and overall
+	if r.SMT != nil {
+		writeMetricHeader(&b, "cpubench1a_smt_core_yield", "gauge", "Throughput gained by all the sibling threads of a core, relative to a single thread")
+		for _, c := range r.SMT.Cores {
+			l := metricLabels{"mode", "smt", "socket", c.Socket, "core_id", c.CoreID, "node", strconv.Itoa(c.Node)}
+			writeMetric(&b, "cpubench1a_smt_core_yield", append(l, common...), c.Yield)
+		}
+		writeMetricHeader(&b, "cpubench1a_smt_yield", "gauge", "Multi-threaded throughput gained by all the threads, relative to one thread per core")
+		writeMetric(&b, "cpubench1a_smt_yield", append(metricLabels{"mode", "smt"}, common...), r.SMT.Yield)
+	}
+	b.WriteString("# EOF\n")
+
+	return writeFileAtomic(filename, b.Bytes())
+}
+
+// writeMetricHeader writes the type (gauge or info) and help description of a
+// metric family. The samples of an info metric family are suffixed by _info.
+func writeMetricHeader(b *bytes.Buffer, name string, typ string, help string) {
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, typ)
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+}
+
+// writeMetric writes a sample of a gauge or an info metric
+func writeMetric(b *bytes.Buffer, name string, labels metricLabels, value float64) {
+	b.WriteString(name)
+	if len(labels) > 0 {
+		b.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				b.WriteByte(',')
+			}
+			fmt.Fprintf(b, "%s=\"%s\"", labels[i], escapeLabel(labels[i+1]))
+		}
+		b.WriteByte('}')
+	}
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	b.WriteByte('\n')
+}
+
+// escapeLabel escapes a label value as required by the exposition format
+func escapeLabel(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s)
+}
+
+// writeFileAtomic writes a file by renaming a temporary file written in the same directory
+func writeFileAtomic(filename string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(filename), "."+filepath.Base(filename)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Chmod(0644); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filename)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteOpenMetrics(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "cpubench1a.prom")
+	r := testReport()
+	r.CPU.Model, r.Host = `Test "CPU"`, "box"
+	r.Flags["threads"] = "8"
+	r.Single.Iterations = []ResultRecord{{Workers: 1, Threads: 8, Throughput: 100.0}, {Workers: 1, Threads: 8, Throughput: 110.0}}
+	r.Score = &Score{Single: 1.5, Multi: 2.5}
+	if err := r.WriteOpenMetrics(name); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := string(b)
+
+	expected := []string{
+		"# TYPE cpubench1a_info gauge\n# HELP cpubench1a_info Information about the benchmark run\n",
+		`cpubench1a_info{mode="bench",host="box",partial="false",custom_mix="false",version="` + Version + `",cpu_model="Test \"CPU\""} 1` + "\n",
+		"# TYPE cpubench1a_throughput gauge\n",
+		`cpubench1a_throughput{mode="single",threads="8",workers="1",iteration="2",version="` + Version + `",cpu_model="Test \"CPU\""} 110` + "\n",
+		`cpubench1a_throughput_stat{mode="single",threads="8",workers="1",stat="max",`,
+		`cpubench1a_score{mode="multi",version="` + Version + `",cpu_model="Test \"CPU\""} 2.5` + "\n",
+	}
+	for _, s := range expected {
+		if !strings.Contains(out, s) {
+			t.Errorf("missing %q in:\n%s", s, out)
+		}
+	}
+	if !strings.HasSuffix(out, "\n# EOF\n") || strings.Contains(out, "cpubench1a_oltp") {
+		t.Errorf("unexpected exposition:\n%s", out)
+	}
+
+	// Each metric family is declared once, before its samples
+	types := map[string]bool{}
+	for _, line := range strings.Split(out, "\n") {
+		if f := strings.Fields(line); len(f) == 4 && f[1] == "TYPE" {
+			if types[f[2]] || f[3] != "gauge" {
+				t.Errorf("unexpected declaration: %s", line)
+			}
+			types[f[2]] = true
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// Profile is a custom transaction mix, defined in a JSON file. Each transaction
+// type is a sequence of algorithms, drawn with a probability relative to the
+// other types. The seed makes the sequence of transaction types reproducible
+// (see RandomSeed).
+type Profile struct {
+	Name         string               `json:"name"`
+	Seed         uint64               `json:"seed,omitempty"`
+	Awk          []UserAwk            `json:"awk,omitempty"`
+	Transactions []ProfileTransaction `json:"transactions"`
+}
+
+// ProfileTransaction is a transaction type of a profile
+type ProfileTransaction struct {
+	Name        string        `json:"name"`
+	Probability float64       `json:"probability"`
+	Steps       []ProfileStep `json:"steps"`
+}
+
+// ProfileStep runs an algorithm a number of times (1 by default)
+type ProfileStep struct {
+	Workload string `json:"workload"`
+	Repeat   int    `json:"repeat,omitempty"`
+}
+
+// profile is the profile loaded with the -profile option
+var profile *Profile
+
+// LoadProfile reads and checks a profile. The AWK workloads of the profile are
+// registered (their files are relative to the profile), and the algorithms of
+// the transaction types must be registered.
+func LoadProfile(filename string) (*Profile, error) {
+
+	b, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	var p Profile
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	for i := range p.Awk {
+		if _, err := p.Awk[i].register(filepath.Dir(filename)); err != nil {
+			return nil, fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+	if err := p.check(); err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+	return &p, nil
+}
+
+// check validates the transaction types of the profile
+func (p *Profile) check() error {
+	if p.Name == "" {
+		return errors.New("the profile has no name")
+	}
+	if len(p.Transactions) == 0 {
+		return errors.New("the profile has no transaction type")
+	}
+	names := map[string]bool{}
+	for _, t := range p.Transactions {
+		if t.Name == "" || names[t.Name] {
+			return fmt.Errorf("missing or duplicate transaction type name %q", t.Name)
+		}
+		names[t.Name] = true
+		if t.Probability <= 0.0 {
+			return fmt.Errorf("transaction type %q: the probability must be positive", t.Name)
+		}
+		if len(t.Steps) == 0 {
+			return fmt.Errorf("transaction type %q has no step", t.Name)
+		}
+		for _, s := range t.Steps {
+			if _, ok := workloadRegistry[s.Workload]; !ok {
+				return fmt.Errorf("transaction type %q: unknown workload %q (available: %s)", t.Name, s.Workload, describeWorkloads(registeredWorkloads()))
+			}
+			if s.Repeat < 0 {
+				return fmt.Errorf("transaction type %q: negative repeat count for %s", t.Name, s.Workload)
+			}
+		}
+	}
+	return nil
+}
+
+// Mix returns the algorithms used by the profile, which are built by each worker
+func (p *Profile) Mix() WorkloadMix {
+	var res WorkloadMix
+	for _, def := range registeredWorkloads() {
+		used := slices.ContainsFunc(p.Transactions, func(t ProfileTransaction) bool {
+			return slices.ContainsFunc(t.Steps, func(s ProfileStep) bool { return s.Workload == def.Name })
+		})
+		if used {
+			res = append(res, MixEntry{Name: def.Name, Weight: 1})
+		}
+	}
+	return res
+}
+
+// Types returns the transaction types of the profile for the benchmark engine
+func (p *Profile) Types() []bench.TransactionType {
+	res := make([]bench.TransactionType, len(p.Transactions))
+	for i, t := range p.Transactions {
+		res[i] = bench.TransactionType{Name: t.Name, Probability: t.Probability}
+		for _, s := range t.Steps {
+			res[i].Steps = append(res[i].Steps, bench.TransactionStep{Workload: s.Workload, Repeat: s.Repeat})
+		}
+	}
+	return res
+}
+
+// Label identifies the profile in the results: its name and a digest of its
+// content, so different profiles with the same name are never confused
+func (p *Profile) Label() string {
+	h := p.digest()
+	return "profile:" + p.Name + "@" + hex.EncodeToString(h[:4])
+}
+
+// RandomSeed returns the seed of the random generators drawing the transaction
+// types. Without seed, it is derived from the content of the profile, so two
+// different profiles do not draw the same sequence of types.
+func (p *Profile) RandomSeed() uint64 {
+	if p.Seed != 0 {
+		return p.Seed
+	}
+	h := p.digest()
+	return binary.LittleEndian.Uint64(h[:8])
+}
+
+// digest returns the SHA-256 hash of the content of the profile. The profile
+// only refers to the files of its AWK workloads, so the digests of their
+// scripts and inputs are part of the hash.
+func (p *Profile) digest() [sha256.Size]byte {
+	h := sha256.New()
+	b, _ := json.Marshal(p)
+	h.Write(b)
+	for _, u := range p.Awk {
+		fmt.Fprintf(h, "\n%s@%s", u.Name, workloadRegistry["awk:"+u.Name].Digest)
+	}
+	var res [sha256.Size]byte
+	h.Sum(res[:0])
+	return res
+}
+
+// selectWorkloads selects the algorithms run at each transaction, from the
+// -workloads and -awkscript options, or the -profile option
+func selectWorkloads() error {
+	if *flagProfile == "" {
+		mix, err := ParseMix(*flagWorkloads)
+		if err != nil {
+			return err
+		}
+
+		// The user AWK workload runs after the selected algorithms
+		if u := awkFlagWorkload(); u != nil {
+			name, err := u.register("")
+			if err != nil {
+				return err
+			}
+			mix = append(mix, MixEntry{Name: name, Weight: 1})
+			*flagAwkHash = u.SHA256
+		}
+		workloadMix = mix
+		return nil
+	}
+	if *flagWorkloads != "" || *flagAwkScript != "" {
+		return errors.New("-workloads and -awkscript cannot be used with -profile (the profile defines the AWK workloads)")
+	}
+	p, err := LoadProfile(*flagProfile)
+	if err != nil {
+		return err
+	}
+	profile, workloadMix = p, p.Mix()
+	return nil
+}
+
+// customMix returns true if the transactions differ from the standard benchmark
+func customMix() bool {
+	return profile != nil || !workloadMix.Canonical()
+}
+
+// mixLabel returns the mix recorded with the results (empty for the canonical
+// mix). The pinning policy is not part of the mix: it is recorded on its own.
+func mixLabel() string {
+	if profile != nil {
+		return profile.Label()
+	}
+	return workloadMix.Label()
+}
+
+// averageTypes calculates the average throughput and cost of each transaction
+// type over several iterations
+func averageTypes(recs []ResultRecord) []bench.TypeStat {
+	var res []bench.TypeStat
+	n := 0
+	for _, rec := range recs {
+		if len(rec.Types) == 0 {
+			continue
+		}
+		if res == nil {
+			res = make([]bench.TypeStat, len(rec.Types))
+			for i, t := range rec.Types {
+				res[i].Name = t.Name
+			}
+		}
+		if len(rec.Types) != len(res) {
+			continue
+		}
+		for i, t := range rec.Types {
+			res[i].Transactions += t.Transactions
+			res[i].Throughput += t.Throughput
+			res[i].Cost += t.Cost
+		}
+		n++
+	}
+	for i := range res {
+		res[i].Transactions /= n
+		res[i].Throughput /= float64(n)
+		res[i].Cost /= float64(n)
+	}
+	return res
+}
+
+// displayTypes displays the throughput, the share and the cost of each transaction type
+func displayTypes(title string, types []bench.TypeStat) {
+	if len(types) == 0 {
+		return
+	}
+	total := 0.0
+	for _, t := range types {
+		total += t.Throughput
+	}
+	log.Print(title)
+	for _, t := range types {
+		log.Printf("    %-16s %12.3f tps %6.2f%% %12.1f us/transaction", t.Name, t.Throughput, 100.0*t.Throughput/total, t.Cost/1000.0)
+	}
+	log.Print()
+}
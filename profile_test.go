@@ -0,0 +1,81 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadProfile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(content string) string {
+		name := filepath.Join(dir, "profile.json")
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return name
+	}
+
+	p, err := LoadProfile(write(`{
+		"name": "api",
+		"seed": 7,
+		"transactions": [
+			{"name": "get", "probability": 0.8, "steps": [{"workload": "json"}, {"workload": "logging", "repeat": 2}]},
+			{"name": "batch", "probability": 0.2, "steps": [{"workload": "simulation"}]}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m := p.Mix().String(); m != "json=1,simulation=1,logging=1" {
+		t.Errorf("mix=%s", m)
+	}
+	types := p.Types()
+	if len(types) != 2 || types[0].Steps[1].Repeat != 2 || p.RandomSeed() != 7 {
+		t.Errorf("unexpected types: %+v", types)
+	}
+
+	// Without seed, different profiles draw different sequences of types
+	q, r := *p, *p
+	q.Seed, r.Seed, r.Name = 0, 0, "other"
+	if q.RandomSeed() == 0 || q.RandomSeed() == r.RandomSeed() {
+		t.Errorf("unexpected derived seeds: %d, %d", q.RandomSeed(), r.RandomSeed())
+	}
+	if l := p.Label(); !strings.HasPrefix(l, "profile:api@") {
+		t.Errorf("label=%s", l)
+	}
+
+	// The AWK workloads are identified by the content of their files, not by their path
+	load := func(script string) string {
+		t.Helper()
+		delete(workloadRegistry, "awk:count")
+		t.Cleanup(func() { delete(workloadRegistry, "awk:count") })
+		if err := os.WriteFile(filepath.Join(dir, "count.awk"), []byte(script), 0644); err != nil {
+			t.Fatal(err)
+		}
+		p, err := LoadProfile(write(`{
+			"name": "awk",
+			"awk": [{"name": "count", "script": "count.awk", "sha256": "01ba4719c80b6fe911b091a7c05124b64eeece964e09c058ef8f9805daca546b"}],
+			"transactions": [{"name": "t", "probability": 1, "steps": [{"workload": "awk:count"}]}]
+		}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p.Label()
+	}
+	if l1, l2 := load(`BEGIN { print "" }`), load(`BEGIN { printf "\n" }`); l1 == l2 {
+		t.Errorf("same label %s for different scripts", l1)
+	}
+
+	for _, content := range []string{
+		`{"name": "x", "transactions": []}`,
+		`{"name": "x", "transactions": [{"name": "t", "probability": 1, "steps": [{"workload": "unknown"}]}]}`,
+		`{"name": "x", "transactions": [{"name": "t", "probability": 0, "steps": [{"workload": "json"}]}]}`,
+		`{"name": "x", "transactions": [{"name": "t", "probability": 1, "steps": [{"workload": "json"}]}], "bogus": 1}`,
+	} {
+		if _, err := LoadProfile(write(content)); err == nil {
+			t.Errorf("profile %s should be rejected", content)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+	"time"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// ReportFormat is the version of the layout of the JSON report.
+// It must be incremented for any incompatible change of the document.
+const ReportFormat = 1
+
+// Report is the machine-readable result document of a benchmark run
+type Report struct {
+	Format  int               `json:"format"`
+	Version string            `json:"version"`
+	Mode    string            `json:"mode"`
+	Partial bool              `json:"partial,omitempty"`
+	Mix     WorkloadMix       `json:"mix"`
+	Custom  bool              `json:"custom_mix,omitempty"`
+	Label   string            `json:"mix_label,omitempty"`
+	Pin     string            `json:"pin,omitempty"`
+	Profile *Profile          `json:"profile,omitempty"`
+	Host    string            `json:"host"`
+	Start   time.Time         `json:"start"`
+	End     time.Time         `json:"end"`
+	CPU     CPUInfo           `json:"cpu"`
+	Flags   map[string]string `json:"flags"`
+	Single  *ReportSeries     `json:"single,omitempty"`
+	Multi   *ReportSeries     `json:"multi,omitempty"`
+	Score   *Score            `json:"score,omitempty"`
+	OLTP    []OLTPPoint       `json:"oltp,omitempty"`
+	Scaling []ScalingPoint    `json:"scaling,omitempty"`
+	Fits    []*ScalabilityFit `json:"fits,omitempty"`
+	NUMA    *NumaResult       `json:"numa,omitempty"`
+	Sweep   []CPUPoint        `json:"cpusweep,omitempty"`
+	SMT     *SMTResult        `json:"smt,omitempty"`
+}
+
+// CPUInfo describes the CPU of the machine running the benchmark
+type CPUInfo struct {
+	Vendor  string    `json:"vendor"`
+	Model   string    `json:"model"`
+	Mhz     float64   `json:"mhz"`
+	Cores   int       `json:"cores"`
+	Threads int       `json:"threads"`
+	Numa    []NumaCPU `json:"numa,omitempty"`
+}
+
+// NumaCPU gives the location of a logical processor in the NUMA topology
+type NumaCPU struct {
+	CPU    int    `json:"cpu"`
+	Socket string `json:"socket"`
+	CoreID string `json:"core_id"`
+	Node   int    `json:"node"`
+}
+
+// ReportSeries contains the throughput of the iterations of a given phase, and their statistics
+type ReportSeries struct {
+	Workers    int              `json:"workers"`
+	Throughput []float64        `json:"throughput"`
+	Stat       bench.Stat       `json:"stat"`
+	Types      []bench.TypeStat `json:"types,omitempty"`
+	Iterations []ResultRecord   `json:"iterations"`
+}
+
+// OLTPPoint is the CPU usage and latency measured for a given target throughput
+type OLTPPoint struct {
+	TPS        int            `json:"tps"`
+	Throughput float64        `json:"throughput"`
+	CPUUsage   float64        `json:"cpu_usage"`
+	Latency    *bench.Latency `json:"latency,omitempty"`
+}
+
+// NewReport creates a report for a run starting now
+func NewReport(mode string, cpu CPUInfo) *Report {
+
+	// Record all the flags, including the default values
+	flags := map[string]string{}
+	flag.VisitAll(func(f *flag.Flag) {
+		flags[f.Name] = f.Value.String()
+	})
+
+	host, _ := os.Hostname()
+	return &Report{
+		Format:  ReportFormat,
+		Version: Version,
+		Mode:    mode,
+		Host:    host,
+		Start:   time.Now(),
+		CPU:     cpu,
+		Flags:   flags,
+		Mix:     workloadMix,
+		Custom:  customMix(),
+		Label:   mixLabel(),
+		Pin:     *flagPin,
+		Profile: profile,
+	}
+}
+
+// NewReportSeries builds a series from the results of a given number of workers
+func NewReportSeries(rf *ResultFile, workers int) *ReportSeries {
+	recs := rf.Select(workers)
+	if len(recs) == 0 {
+		return nil
+	}
+	r := make([]float64, 0, len(recs))
+	for _, rec := range recs {
+		r = append(r, rec.Throughput)
+	}
+	return &ReportSeries{
+		Workers:    workers,
+		Throughput: r,
+		Stat:       bench.ComputeStat(r),
+		Types:      averageTypes(recs),
+		Iterations: recs,
+	}
+}
+
+// writeReports completes the report, writes it in all the requested formats,
+// and records it in the local history (unless the run is partial)
+func writeReports(r *Report) error {
+	r.End = time.Now()
+	if !*flagNoHist && !r.Partial {
+		if err := AppendHistory(r); err != nil {
+			log.Printf("Cannot record the run in the history: %v", err)
+		}
+	}
+	if *flagJSON != "" {
+		if err := r.WriteJSON(*flagJSON); err != nil {
+			return err
+		}
+	}
+	if *flagHTML != "" {
+		if err := r.WriteHTML(*flagHTML); err != nil {
+			return err
+		}
+	}
+	if *flagProm != "" {
+		if err := r.WriteOpenMetrics(*flagProm); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteJSON stores the report as a JSON document
+func (r *Report) WriteJSON(filename string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, append(b, '\n'), 0644)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+func testReport() *Report {
+	r := NewReport("bench", CPUInfo{Vendor: "test", Model: "test", Cores: 4, Threads: 8})
+	r.End = r.Start.Add(time.Minute)
+	r.Mix, r.Custom = canonicalMix(), false
+	r.Single = &ReportSeries{Workers: 1, Throughput: []float64{100.0, 110.0}, Stat: bench.ComputeStat([]float64{100.0, 110.0})}
+	return r
+}
+
+func TestWriteJSON(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "report.json")
+	r := testReport()
+	if err := r.WriteJSON(name); err != nil {
+		t.Fatal(err)
+	}
+
+	// The document is the one of the standard encoder
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected, _ := json.MarshalIndent(r, "", "  ")
+	if string(b) != string(expected)+"\n" {
+		t.Errorf("unexpected document:\n%s\nexpected:\n%s", b, expected)
+	}
+}
+
+func TestWriteJSONNonFinite(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "report.json")
+
+	// A zero throughput (e.g. after an interruption) gives a NaN geometric mean
+	r := testReport()
+	r.Partial = true
+	r.Multi = &ReportSeries{Workers: 8, Throughput: []float64{0.0, 800.0}, Stat: bench.ComputeStat([]float64{0.0, 800.0})}
+	if err := r.WriteJSON(name); err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Partial bool
+		Multi   struct {
+			Throughput []float64
+			Stat       map[string]*float64
+		}
+	}
+	if err := json.Unmarshal(b, &doc); err != nil {
+		t.Fatal(err)
+	}
+	if !doc.Partial || len(doc.Multi.Throughput) != 2 {
+		t.Errorf("unexpected document: %s", b)
+	}
+	if g, ok := doc.Multi.Stat["geo_mean"]; !ok || g != nil {
+		t.Errorf("non-finite value should be null: %v", g)
+	}
+	if m := doc.Multi.Stat["max"]; m == nil || *m != 800.0 {
+		t.Errorf("unexpected max: %v", m)
+	}
+}
+
+func TestReportMixLabel(t *testing.T) {
+	pin, mix := *flagPin, workloadMix
+	defer func() { *flagPin, workloadMix = pin, mix }()
+	workloadMix = canonicalMix()
+
+	// The report records the same label as the iterations, and the pinning policy on its own
+	*flagPin = "compact"
+	r := NewReport("bench", CPUInfo{})
+	if r.Label != mixLabel() || NewHistoryEntry(r).Mix != r.Label || strings.Contains(r.Label, "compact") {
+		t.Errorf("label=%q, expected %q", r.Label, mixLabel())
+	}
+	if r.Pin != "compact" || NewHistoryEntry(r).Pin != "compact" || r.Custom {
+		t.Errorf("unexpected pinning policy: %q (custom mix %v)", r.Pin, r.Custom)
+	}
+}
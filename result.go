@@ -0,0 +1,258 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// ResultFormat is the version of the result file format.
+// Version 1 is the legacy format (one "workers throughput" line per iteration).
+// Version 2 is made of JSON lines: a header followed by one record per iteration.
+const ResultFormat = 2
+
+// ResultMap stores the results indexed by number of workers
+type ResultMap map[int][]float64
+
+// ResultHeader is the first line of a result file. It describes the binary and the host
+// which have produced the results.
+type ResultHeader struct {
+	Type      string `json:"type"`
+	Format    int    `json:"format"`
+	Version   string `json:"version"`
+	GoVersion string `json:"go_version,omitempty"`
+	OS        string `json:"os,omitempty"`
+	Arch      string `json:"arch,omitempty"`
+	Host      string `json:"host,omitempty"`
+}
+
+// ResultRecord is the result of a single benchmark iteration
+type ResultRecord struct {
+	Type         string               `json:"type"`
+	Mode         string               `json:"mode"`
+	Workers      int                  `json:"workers"`
+	Threads      int                  `json:"threads,omitempty"`
+	Duration     int                  `json:"duration,omitempty"`
+	Warmup       float64              `json:"warmup,omitempty"`
+	Start        time.Time            `json:"start"`
+	End          time.Time            `json:"end"`
+	Transactions int                  `json:"transactions,omitempty"`
+	Throughput   float64              `json:"throughput"`
+	Breakdown    []bench.WorkloadCost `json:"breakdown,omitempty"`
+	Latency      *bench.Latency       `json:"latency,omitempty"`
+	Timeline     *bench.Timeline      `json:"timeline,omitempty"`
+	CPUSet       string               `json:"cpuset,omitempty"`
+	Pin          string               `json:"pin,omitempty"`
+	Placement    Placement            `json:"placement,omitempty"`
+	Mix          string               `json:"mix,omitempty"`
+	Types        []bench.TypeStat     `json:"types,omitempty"`
+}
+
+// ResultFile is the decoded content of a result file
+type ResultFile struct {
+	Header  ResultHeader
+	Records []ResultRecord
+}
+
+// Types of the lines of a result file
+const (
+	ResultTypeHeader    = "header"
+	ResultTypeIteration = "iteration"
+)
+
+// NewResultHeader builds the header describing the current binary and host
+func NewResultHeader() ResultHeader {
+	host, _ := os.Hostname()
+	return ResultHeader{
+		Type:      ResultTypeHeader,
+		Format:    ResultFormat,
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		Host:      host,
+	}
+}
+
+// AppendResult writes the result at the end of the temporary file.
+// The header is written first if the file is empty.
+func AppendResult(resfile string, rec ResultRecord) error {
+
+	f, err := os.OpenFile(resfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	st, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	if st.Size() == 0 {
+		if err := enc.Encode(NewResultHeader()); err != nil {
+			return err
+		}
+	}
+	rec.Type = ResultTypeIteration
+	return enc.Encode(rec)
+}
+
+// readResult reads the temporary file and build a map of the results
+func readResult(f *os.File) (ResultMap, error) {
+	rf, err := readResultFile(f)
+	if err != nil {
+		return nil, err
+	}
+	return rf.Map(), nil
+}
+
+// readResultFile reads and decodes a whole result file
+func readResultFile(f *os.File) (*ResultFile, error) {
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	rf, err := DecodeResult(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", f.Name(), err)
+	}
+	return rf, nil
+}
+
+// LoadResult reads and decodes a result file from its name
+func LoadResult(filename string) (*ResultFile, error) {
+	f, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readResultFile(f)
+}
+
+// DecodeResult decodes the content of a result file. Both the current and
+// the legacy formats are accepted.
+func DecodeResult(r io.Reader) (*ResultFile, error) {
+
+	rf := &ResultFile{Header: ResultHeader{Type: ResultTypeHeader, Format: 1}}
+
+	// Scan the file, one record by line
+	scan := bufio.NewScanner(r)
+	scan.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scan.Scan(); line++ {
+
+		s := strings.TrimSpace(scan.Text())
+		if s == "" {
+			continue
+		}
+
+		// Decode a legacy record: exactly a number of workers and a throughput
+		if s[0] != '{' {
+			rec, err := decodeLegacyRecord(s)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid legacy record %q", line, s)
+			}
+			rf.Records = append(rf.Records, rec)
+			continue
+		}
+
+		// Decode a typed record
+		var t struct {
+			Type string `json:"type"`
+		}
+		if err := json.Unmarshal([]byte(s), &t); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		switch t.Type {
+		case ResultTypeHeader:
+			if err := json.Unmarshal([]byte(s), &rf.Header); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			if rf.Header.Format > ResultFormat {
+				return nil, fmt.Errorf("line %d: unsupported result format %d", line, rf.Header.Format)
+			}
+		case ResultTypeIteration:
+			var rec ResultRecord
+			if err := json.Unmarshal([]byte(s), &rec); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			rf.Records = append(rf.Records, rec)
+		default:
+			// Ignore unknown record types so that newer files can still be partially read
+		}
+	}
+	if err := scan.Err(); err != nil {
+		return nil, err
+	}
+
+	return rf, nil
+}
+
+// decodeLegacyRecord decodes a legacy record made of a number of workers and a throughput
+func decodeLegacyRecord(s string) (ResultRecord, error) {
+	rec := ResultRecord{Type: ResultTypeIteration, Mode: "run"}
+	f := strings.Fields(s)
+	if len(f) != 2 {
+		return rec, fmt.Errorf("%d fields, expected 2", len(f))
+	}
+	var err error
+	if rec.Workers, err = strconv.Atoi(f[0]); err != nil {
+		return rec, err
+	}
+	rec.Throughput, err = strconv.ParseFloat(f[1], 64)
+	return rec, err
+}
+
+// Map builds a map of the throughput results indexed by number of workers
+func (rf *ResultFile) Map() ResultMap {
+	m := ResultMap{}
+	for _, rec := range rf.Records {
+		m[rec.Workers] = append(m[rec.Workers], rec.Throughput)
+	}
+	return m
+}
+
+// Mix returns the workload mix of the iterations (empty for the canonical mix).
+// An error is returned if the iterations have been run with different mixes.
+func (rf *ResultFile) Mix() (string, error) {
+	return rf.common("workload mixes", func(rec ResultRecord) string { return rec.Mix })
+}
+
+// Pin returns the pinning policy of the workers of the iterations (empty if
+// they are not pinned). An error is returned if the iterations have been run
+// with different policies.
+func (rf *ResultFile) Pin() (string, error) {
+	return rf.common("pinning policies", func(rec ResultRecord) string { return rec.Pin })
+}
+
+// common returns the value of a field shared by all the iterations
+func (rf *ResultFile) common(what string, get func(ResultRecord) string) (string, error) {
+	if len(rf.Records) == 0 {
+		return "", nil
+	}
+	res := get(rf.Records[0])
+	for _, rec := range rf.Records {
+		if v := get(rec); v != res {
+			return "", fmt.Errorf("iterations run with different %s (%q and %q)", what, res, v)
+		}
+	}
+	return res, nil
+}
+
+// Select returns the records corresponding to a given number of workers
+func (rf *ResultFile) Select(workers int) []ResultRecord {
+	var res []ResultRecord
+	for _, rec := range rf.Records {
+		if rec.Workers == workers {
+			res = append(res, rec)
+		}
+	}
+	return res
+}
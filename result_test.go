@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeLegacyResult(t *testing.T) {
+	rf, err := DecodeResult(strings.NewReader("1 246.161850\n8 1471.262467\n1 250.000000\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.Header.Format != 1 || len(rf.Records) != 3 {
+		t.Fatalf("unexpected decoding: %+v", rf)
+	}
+	m := rf.Map()
+	if len(m[1]) != 2 || len(m[8]) != 1 || m[8][0] != 1471.262467 {
+		t.Errorf("unexpected map: %v", m)
+	}
+}
+
+func TestDecodeInvalidResult(t *testing.T) {
+	for _, s := range []string{"foo", "1", "1 246.161850 garbage", "1 246.161850x", "1.5 246.161850"} {
+		if _, err := DecodeResult(strings.NewReader("1 246.161850\n" + s + "\n")); err == nil {
+			t.Errorf("invalid record %q should be rejected", s)
+		}
+	}
+	if _, err := DecodeResult(strings.NewReader(`{"type":"header","format":99}` + "\n")); err == nil {
+		t.Error("unsupported format should be rejected")
+	}
+}
+
+func TestAppendResult(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "res")
+	begin := time.Now()
+	for _, w := range []int{1, 1, 16} {
+		rec := ResultRecord{Mode: "run", Workers: w, Threads: 4, Duration: 60, Start: begin, End: begin.Add(time.Minute), Throughput: float64(w) * 100.0}
+		if err := AppendResult(name, rec); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rf, err := LoadResult(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rf.Header.Format != ResultFormat || rf.Header.Version != Version {
+		t.Errorf("unexpected header: %+v", rf.Header)
+	}
+	if len(rf.Records) != 3 || len(rf.Select(1)) != 2 {
+		t.Fatalf("unexpected records: %+v", rf.Records)
+	}
+	rec := rf.Select(16)[0]
+	if rec.Threads != 4 || rec.Duration != 60 || rec.Throughput != 1600.0 || !rec.Start.Equal(begin) {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+
+	// Only one header is expected
+	b, _ := os.ReadFile(name)
+	if n := strings.Count(string(b), `"type":"header"`); n != 1 {
+		t.Errorf("%d headers found", n)
+	}
+}
+
+func TestResultPin(t *testing.T) {
+	rf := &ResultFile{Records: []ResultRecord{{Workers: 1, Pin: "compact"}, {Workers: 8, Pin: "compact"}}}
+	if pin, err := rf.Pin(); err != nil || pin != "compact" {
+		t.Errorf("pin=%q, err=%v", pin, err)
+	}
+	if mix, err := rf.Mix(); err != nil || mix != "" {
+		t.Errorf("the pinning policy is not part of the mix: %q, %v", mix, err)
+	}
+	rf.Records = append(rf.Records, ResultRecord{Workers: 8})
+	if _, err := rf.Pin(); err == nil {
+		t.Error("different pinning policies should be rejected")
+	}
+}
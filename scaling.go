@@ -0,0 +1,99 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// ScalingPoint is the result of the scaling benchmark for a given number of workers
+type ScalingPoint struct {
+	Workers    int        `json:"workers"`
+	Throughput float64    `json:"throughput"`
+	Speedup    float64    `json:"speedup"`
+	Efficiency float64    `json:"efficiency"`
+	Stat       bench.Stat `json:"stat"`
+}
+
+// scalingPoints returns the numbers of workers of the scaling benchmark: powers
+// of two up to the number of threads (always included), or every count.
+func scalingPoints(threads int, all bool) []int {
+	var res []int
+	for n := 1; n < threads; n *= 2 {
+		res = append(res, n)
+	}
+	if all {
+		res = res[:0]
+		for n := 1; n < threads; n++ {
+			res = append(res, n)
+		}
+	}
+	return append(res, threads)
+}
+
+// scalingBench runs the benchmark for an increasing number of workers, and
+// reports the speedup and parallel efficiency of each point, and the fitted
+// scalability models
+func scalingBench() error {
+
+	report, err := startMode("scaling")
+	if err != nil {
+		return err
+	}
+
+	// Each point runs with all the threads, but the concurrency is limited by the number of workers
+	ph := benchPhase{Title: "Scaling performance"}
+	for _, n := range scalingPoints(*flagThreads, *flagScalingAll) {
+		ph.Points = append(ph.Points, benchPoint{Threads: *flagThreads, Workers: n, Duration: *flagDuration, Iterations: *flagNb})
+	}
+	rf, err := runPhases(report, []benchPhase{ph})
+	if err != nil {
+		return err
+	}
+
+	// Display the scaling curve
+	report.Scaling = NewScaling(rf.Map())
+	displayScaling(report.Scaling)
+	report.Fits = fitModels(report.Scaling)
+	for _, fit := range report.Fits {
+		displayFit(fit)
+	}
+	return endMode(report)
+}
+
+// NewScaling calculates the speedup and efficiency of each number of workers,
+// relative to the single worker throughput
+func NewScaling(m ResultMap) []ScalingPoint {
+
+	workers := make([]int, 0, len(m))
+	for w := range m {
+		workers = append(workers, w)
+	}
+	sort.Ints(workers)
+
+	var res []ScalingPoint
+	ref := bench.ComputeStat(m[1]).Max
+	for _, w := range workers {
+		s := bench.ComputeStat(m[w])
+		pt := ScalingPoint{Workers: w, Throughput: s.Max, Stat: s}
+		if ref > 0.0 {
+			pt.Speedup = s.Max / ref
+			pt.Efficiency = pt.Speedup / float64(w)
+		}
+		res = append(res, pt)
+	}
+	return res
+}
+
+// displayScaling displays the scaling curve
+func displayScaling(points []ScalingPoint) {
+	log.Print("Scaling results")
+	log.Print("===============")
+	log.Print()
+	log.Print("    Workers     Throughput    Speedup  Efficiency")
+	for _, pt := range points {
+		log.Printf("    %7d %14.6f %10.3f %10.1f%%", pt.Workers, pt.Throughput, pt.Speedup, 100.0*pt.Efficiency)
+	}
+	log.Print()
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"math"
+	"slices"
+	"testing"
+)
+
+func TestScalingPoints(t *testing.T) {
+	tests := []struct {
+		threads  int
+		all      bool
+		expected []int
+	}{
+		{1, false, []int{1}},
+		{8, false, []int{1, 2, 4, 8}},
+		{12, false, []int{1, 2, 4, 8, 12}},
+		{4, true, []int{1, 2, 3, 4}},
+	}
+	for _, tt := range tests {
+		if res := scalingPoints(tt.threads, tt.all); !slices.Equal(res, tt.expected) {
+			t.Errorf("scalingPoints(%d, %v) = %v, expected %v", tt.threads, tt.all, res, tt.expected)
+		}
+	}
+}
+
+func TestNewScaling(t *testing.T) {
+	res := NewScaling(ResultMap{1: {90, 100}, 4: {380, 360}, 2: {190}})
+	if len(res) != 3 || res[1].Workers != 2 || res[2].Workers != 4 {
+		t.Fatalf("unexpected points: %+v", res)
+	}
+	if math.Abs(res[2].Speedup-3.8) > 1e-9 || math.Abs(res[2].Efficiency-0.95) > 1e-9 {
+		t.Errorf("speedup=%v efficiency=%v", res[2].Speedup, res[2].Efficiency)
+	}
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"runtime"
+	"slices"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// SMTRun is the throughput of the benchmark restricted to a set of CPUs
+type SMTRun struct {
+	CPUs       string     `json:"cpus"`
+	Threads    int        `json:"threads"`
+	Workers    int        `json:"workers"`
+	Throughput float64    `json:"throughput"`
+	Stat       bench.Stat `json:"stat"`
+}
+
+// SMTCore is the SMT yield of a physical core: the throughput of all its
+// sibling threads compared to the throughput of a single thread
+type SMTCore struct {
+	Socket   string  `json:"socket"`
+	CoreID   string  `json:"core_id"`
+	Node     int     `json:"node"`
+	Single   SMTRun  `json:"single"`
+	Siblings SMTRun  `json:"siblings"`
+	Yield    float64 `json:"yield"`
+}
+
+// SMTResult is the result of the SMT benchmark. The overall yield compares the
+// multi-threaded throughput on all the logical CPUs with the throughput using
+// one thread per physical core.
+type SMTResult struct {
+	Cores    []SMTCore `json:"cores"`
+	Physical SMTRun    `json:"physical"`
+	Logical  SMTRun    `json:"logical"`
+	Yield    float64   `json:"yield"`
+}
+
+// smtBench measures the yield of SMT (hyper-threading), per core and overall
+func smtBench() error {
+
+	report, err := startMode("smt")
+	if err != nil {
+		return err
+	}
+	if !affinitySupported {
+		return fmt.Errorf("the SMT benchmark is not supported on %s", runtime.GOOS)
+	}
+	topo := report.CPU.Numa
+	if len(topo) == 0 {
+		if topo, err = cpuTopology(); err != nil {
+			return err
+		}
+	}
+	res := newSMTResult(topo, max(*flagWorkers / *flagThreads, 1))
+	if len(res.Cores) == 0 {
+		return errors.New("SMT is not enabled (no physical core with sibling threads)")
+	}
+
+	// Run a short benchmark with one thread then all the siblings of each core,
+	// then the multi-threaded benchmark with one thread per core, then all the threads
+	perCore := benchPhase{Title: "SMT performance per core"}
+	for _, c := range res.Cores {
+		for _, r := range []SMTRun{c.Single, c.Siblings} {
+			perCore.Points = append(perCore.Points, r.point(*flagSweepDur, 1))
+		}
+	}
+	multi := benchPhase{Title: "Multi-threaded SMT performance"}
+	for _, r := range []SMTRun{res.Physical, res.Logical} {
+		multi.Points = append(multi.Points, r.point(*flagDuration, *flagNb))
+	}
+	rf, err := runPhases(report, []benchPhase{perCore, multi})
+	if err != nil {
+		return err
+	}
+	res.Fill(rf)
+	displaySMT(res)
+	report.SMT = res
+	return endMode(report)
+}
+
+// newSMTResult builds the runs of the SMT benchmark from the CPU topology.
+// The first thread of each core is the one with the lowest CPU number.
+func newSMTResult(topo []NumaCPU, perThread int) *SMTResult {
+
+	res := &SMTResult{}
+	var primary, all []int
+	order := compactOrder(topo)
+	for i := 0; i < len(order); {
+
+		// The siblings are adjacent in the compact order
+		first := cpuAt(topo, order[i])
+		j := i + 1
+		for j < len(order) {
+			x := cpuAt(topo, order[j])
+			if x.Node != first.Node || x.Socket != first.Socket || x.CoreID != first.CoreID {
+				break
+			}
+			j++
+		}
+		siblings := slices.Sorted(slices.Values(order[i:j]))
+		primary = append(primary, siblings[0])
+		all = append(all, siblings...)
+		if len(siblings) > 1 {
+			res.Cores = append(res.Cores, SMTCore{
+				Socket:   first.Socket,
+				CoreID:   first.CoreID,
+				Node:     first.Node,
+				Single:   SMTRun{CPUs: formatCPUList(siblings[:1]), Threads: 1, Workers: 1},
+				Siblings: SMTRun{CPUs: formatCPUList(siblings), Threads: len(siblings), Workers: len(siblings)},
+			})
+		}
+		i = j
+	}
+
+	slices.Sort(primary)
+	slices.Sort(all)
+	res.Physical = SMTRun{CPUs: formatCPUList(primary), Threads: len(primary), Workers: perThread * len(primary)}
+	res.Logical = SMTRun{CPUs: formatCPUList(all), Threads: len(all), Workers: perThread * len(all)}
+	return res
+}
+
+// point returns the configuration of the iterations of a run
+func (r SMTRun) point(duration, iterations int) benchPoint {
+	return benchPoint{CPUs: r.CPUs, Threads: r.Threads, Workers: r.Workers, Duration: duration, Iterations: iterations}
+}
+
+// Fill associates the iterations to the runs (by CPU set and number of
+// workers), and calculates the yields
+func (r *SMTResult) Fill(rf *ResultFile) {
+	fill := func(run *SMTRun) {
+		run.Stat = rf.PointStat(run.CPUs, run.Workers)
+		run.Throughput = run.Stat.Max
+	}
+	yield := func(single, siblings float64) float64 {
+		if single <= 0.0 {
+			return 0.0
+		}
+		return siblings/single - 1.0
+	}
+	for i := range r.Cores {
+		c := &r.Cores[i]
+		fill(&c.Single)
+		fill(&c.Siblings)
+		c.Yield = yield(c.Single.Throughput, c.Siblings.Throughput)
+	}
+	fill(&r.Physical)
+	fill(&r.Logical)
+	r.Yield = yield(r.Physical.Throughput, r.Logical.Throughput)
+}
+
+// displaySMT displays the SMT yield of each core, and the overall yield
+func displaySMT(r *SMTResult) {
+	log.Print("SMT results")
+	log.Print("===========")
+	log.Print()
+	log.Print("    Socket CoreId Node  CPUs         1 thread       Siblings    Yield")
+	for _, c := range r.Cores {
+		log.Printf("    %6s %6s %4d  %-8s %14.6f %14.6f %7.1f%%", c.Socket, c.CoreID, c.Node, c.Siblings.CPUs, c.Single.Throughput, c.Siblings.Throughput, 100.0*c.Yield)
+	}
+	log.Print()
+	log.Printf("One thread per core: %.6f (%d threads, CPUs %s)", r.Physical.Throughput, r.Physical.Threads, r.Physical.CPUs)
+	log.Printf("    All the threads: %.6f (%d threads, CPUs %s)", r.Logical.Throughput, r.Logical.Threads, r.Logical.CPUs)
+	log.Printf("  Overall SMT yield: %.1f%%", 100.0*r.Yield)
+	log.Print()
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSMTResult(t *testing.T) {
+
+	// testTopology has 4 cores with 2 threads each
+	res := newSMTResult(testTopology(), 4)
+	if len(res.Cores) != 4 || res.Cores[0].Siblings.CPUs != "0,4" || res.Cores[0].Single.CPUs != "0" {
+		t.Fatalf("unexpected cores: %+v", res.Cores)
+	}
+	if res.Physical.CPUs != "0-3" || res.Physical.Workers != 16 || res.Logical.CPUs != "0-7" || res.Logical.Threads != 8 {
+		t.Fatalf("unexpected runs: %+v %+v", res.Physical, res.Logical)
+	}
+
+	rf := &ResultFile{Records: []ResultRecord{
+		{CPUSet: "0", Workers: 1, Throughput: 100},
+		{CPUSet: "0,4", Workers: 2, Throughput: 125},
+		{CPUSet: "0-3", Workers: 16, Throughput: 400},
+		{CPUSet: "0-7", Workers: 32, Throughput: 480},
+	}}
+	res.Fill(rf)
+	if math.Abs(res.Cores[0].Yield-0.25) > 1e-9 || res.Cores[1].Yield != 0.0 || math.Abs(res.Yield-0.2) > 1e-9 {
+		t.Errorf("core yield=%v overall=%v", res.Cores[0].Yield, res.Yield)
+	}
+}
@@ -1,38 +1,14 @@
 package main
 
 import (
-	"bufio"
-	"fmt"
 	"log"
-	"math"
-	"os"
-	"sort"
-)
-
-// ResultMap stores the results indexed by number of workers
-type ResultMap map[int][]float64
-
-// AppendResult writes the result at the end of the temporary file
-func AppendResult(resfile string, workers int, result float64) error {
-
-	f, err := os.OpenFile(resfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	fmt.Fprintf(f, "%d %.6f\n", workers, result)
-	return nil
-}
 
-// DisplayResult displays some statistics about the results
-func DisplayResult(f *os.File, workers int) error {
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
 
-	// Read temporary file
-	m, err := readResult(f)
-	if err != nil {
-		return err
-	}
+// DisplayResult displays some statistics about the results,
+// and the normalized score if a baseline is provided
+func DisplayResult(m ResultMap, workers int, base *Baseline) {
 
 	log.Print("Results")
 	log.Print("=======")
@@ -43,87 +19,43 @@ func DisplayResult(f *os.File, workers int) error {
 	// Display statistics on results
 	displayStat("Single thread", m[1])
 	displayStat("Multi-thread", m[workers])
-
-	return nil
-}
-
-// readResult reads the temporary file and build a map of the results
-func readResult(f *os.File) (ResultMap, error) {
-
-	var workers int
-	var throughput float64
-	m := ResultMap{}
-
-	// Scan the temporary file, one record by line
-	f.Seek(0, 0)
-	scan := bufio.NewScanner(f)
-	for scan.Scan() {
-
-		// Decode a single record
-		n, err := fmt.Sscan(scan.Text(), &workers, &throughput)
-		if err != nil || n != 2 {
-			return nil, err
-		}
-		m[workers] = append(m[workers], throughput)
-	}
-	if err := scan.Err(); err != nil {
-		return nil, err
+	if base != nil {
+		displayScore(NewScore(base, m, workers))
 	}
-
-	return m, nil
 }
 
 // displayStat calculates basic statistics and displays them
 func displayStat(title string, r []float64) {
 
-	// Calculate min, max, and median from sorted results
-	var median float64
-	sort.Float64s(r)
-	min, max := r[0], r[len(r)-1]
-	if len(r)%2 == 0 {
-		a, b := r[len(r)/2-1], r[len(r)/2]
-		median = (a + b) / 2.0
-	} else {
-		median = r[len(r)/2]
-	}
-
-	// Calculate average and geo man
-	avg := average(r)
-	geo := geoMean(r)
+	s := bench.ComputeStat(r)
 
 	// Display
 	log.Print(title)
-	log.Printf("    Minimum: %.6f", min)
-	log.Printf("    Average: %.6f", avg)
-	log.Printf("     Median: %.6f", median)
-	log.Printf("   Geo mean: %.6f", geo)
-	log.Printf("    Maximum: %.6f", max)
-	log.Print()
-}
-
-// average calculates the arithmetic mean
-func average(r []float64) float64 {
-	if len(r) == 0 {
-		return math.NaN()
+	if s.N == 0 {
+		log.Print("    No result")
+		log.Print()
+		return
+	}
+	log.Printf("    Minimum: %.6f", s.Min)
+	log.Printf("    Average: %.6f", s.Average)
+	log.Printf("     Median: %.6f (%.1f%% CI [%.6f, %.6f])", s.Median, 100.0*s.MedianConf, s.MedianLow, s.MedianHigh)
+	log.Printf("   Geo mean: %.6f", s.GeoMean)
+	log.Printf("    Maximum: %.6f", s.Max)
+	log.Printf("    Std dev: %.6f", s.StdDev)
+	log.Printf("        CoV: %.2f%%", s.CoV)
+	for _, i := range s.Outliers {
+		log.Printf("    Outlier: iteration %d (%.6f)", i+1, r[i])
 	}
-	sum := 0.0
-	for _, x := range r {
-		sum += x
-	}
-	return sum / float64(len(r))
+	log.Print()
 }
 
-// geoMean calculates the geometric mean
-func geoMean(r []float64) float64 {
-	if len(r) == 0 {
-		return math.NaN()
-	}
-	sum := 0.0
-	for _, x := range r {
-		if x <= 0.0 {
-			return math.NaN()
-		}
-		sum += math.Log(x)
+// checkNoise warns when the results are too dispersed to be trusted.
+// It returns true if the coefficient of variation exceeds the threshold (in percent).
+func checkNoise(title string, r []float64, threshold float64) bool {
+	s := bench.ComputeStat(r)
+	if threshold <= 0.0 || s.N < 2 || s.CoV <= threshold {
+		return false
 	}
-	return math.Exp(sum / float64(len(r)))
+	log.Printf("WARNING: %s results are noisy (CoV %.2f%% > %.2f%%): check for noisy neighbours or CPU throttling", title, s.CoV, threshold)
+	return true
 }
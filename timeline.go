@@ -0,0 +1,28 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// displayTimeline displays the throughput samples (10 per line) and the detected drops
+func displayTimeline(t *bench.Timeline) {
+	if len(t.Throughput) == 0 {
+		return
+	}
+	log.Printf("Timeline (throughput every %gs, median %.3f)", t.Interval, t.Median)
+	for i := 0; i < len(t.Throughput); i += 10 {
+		var b strings.Builder
+		for _, x := range t.Throughput[i:min(i+10, len(t.Throughput))] {
+			fmt.Fprintf(&b, " %9.3f", x)
+		}
+		log.Printf("    %5gs%s", float64(i)*t.Interval, b.String())
+	}
+	for _, d := range t.Drops {
+		log.Printf("    DROP at %gs: %.3f (%.1f%% below median)", d.Offset, d.Throughput, d.Drop)
+	}
+	log.Print()
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+	"github.com/benhoyt/goawk/interp"
+	"github.com/benhoyt/goawk/parser"
+)
+
+// UserAwk is an AWK workload supplied by the user: a script, an optional input
+// file, and the expected SHA-256 hash of the output. Without expected hash, the
+// output of a first run is the reference.
+type UserAwk struct {
+	Name   string `json:"name"`
+	Script string `json:"script"`
+	Input  string `json:"input,omitempty"`
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// BenchUserAwk runs a user AWK script, parsed once per worker, and checks its
+// output against the reference output (validated by its hash at registration)
+type BenchUserAwk struct {
+	name     string
+	prog     *parser.Program
+	input    []byte
+	expected []byte
+	res      bytes.Buffer
+	config   *interp.Config
+}
+
+// userAwkRank is the rank of the first user AWK workload: they run after the
+// algorithms of the standard benchmark
+const userAwkRank = 1000
+
+// awkFlagWorkload builds the user AWK workload defined by the -awkscript,
+// -awkinput and -awkhash options. It is named after the script file.
+func awkFlagWorkload() *UserAwk {
+	if *flagAwkScript == "" {
+		return nil
+	}
+	name := strings.TrimSuffix(filepath.Base(*flagAwkScript), filepath.Ext(*flagAwkScript))
+	return &UserAwk{Name: name, Script: *flagAwkScript, Input: *flagAwkInput, SHA256: *flagAwkHash}
+}
+
+// register loads the script and its input, runs it once to check the hash of
+// its output, and registers the workload as "awk:" followed by its name. The
+// digest of the workload identifies the script and its input, since different
+// scripts may produce the same output. The files are relative to the directory dir.
+func (u *UserAwk) register(dir string) (string, error) {
+
+	if u.Name == "" || u.Script == "" {
+		return "", fmt.Errorf("AWK workload %q: a name and a script are expected", u.Name)
+	}
+	name := "awk:" + u.Name
+	script, err := os.ReadFile(resolvePath(dir, u.Script))
+	if err != nil {
+		return "", err
+	}
+	var input []byte
+	if u.Input != "" {
+		if input, err = os.ReadFile(resolvePath(dir, u.Input)); err != nil {
+			return "", err
+		}
+	}
+
+	// Check the script, and the hash of its output
+	prog, err := parser.ParseProgram(script, nil)
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", name, err)
+	}
+	var out bytes.Buffer
+	config := newAwkConfig()
+	config.Stdin, config.Output = bytes.NewReader(input), &out
+	if n, err := interp.ExecProgram(prog, config); err != nil || n != 0 {
+		return "", fmt.Errorf("%s: exit status %d: %v", name, n, err)
+	}
+	h := sha256.Sum256(out.Bytes())
+	hash := hex.EncodeToString(h[:])
+	if u.SHA256 != "" && !strings.EqualFold(u.SHA256, hash) {
+		return "", fmt.Errorf("%s: output hash %s, expected %s", name, hash, u.SHA256)
+	}
+	if u.SHA256 == "" {
+		log.Printf("AWK workload %s: reference output hash %s", name, hash)
+		u.SHA256 = hash
+	}
+
+	if _, ok := workloadRegistry[name]; ok {
+		return "", fmt.Errorf("AWK workload %s defined twice", name)
+	}
+	rank := userAwkRank
+	for _, def := range workloadRegistry {
+		rank = max(rank, def.Rank+1)
+	}
+	registerWorkload(WorkloadDef{
+		Rank:     rank,
+		Name:     name,
+		Category: "user",
+		Weight:   1,
+		User:     true,
+		Digest:   awkDigest(script, input),
+		New:      func() bench.Benchmark { return NewBenchUserAwk(name, script, input, out.Bytes()) },
+	})
+	return name, nil
+}
+
+// awkDigest returns a short digest of a script and its input
+func awkDigest(script, input []byte) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%d:", len(script))
+	h.Write(script)
+	h.Write(input)
+	return hex.EncodeToString(h.Sum(nil))[:8]
+}
+
+// resolvePath resolves a relative path against a directory
+func resolvePath(dir, path string) string {
+	if dir == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// NewBenchUserAwk allocates a new benchmark object. The script is parsed once.
+func NewBenchUserAwk(name string, script, input, expected []byte) *BenchUserAwk {
+
+	prog, err := parser.ParseProgram(script, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return &BenchUserAwk{
+		name:     name,
+		prog:     prog,
+		input:    input,
+		expected: expected,
+		config:   newAwkConfig(),
+	}
+}
+
+// newAwkConfig returns the configuration of the interpreter. The scripts
+// cannot run commands or access files: the input is the only source of data.
+func newAwkConfig() *interp.Config {
+	return &interp.Config{NoExec: true, NoFileWrites: true, NoFileReads: true}
+}
+
+// Run executes the AWK program on its input, and checks the output. The
+// comparison with the reference output is part of the measured cost, but it
+// is much cheaper than hashing the output again.
+func (b *BenchUserAwk) Run() {
+
+	b.config.Stdin = bytes.NewReader(b.input)
+	b.config.Output = &b.res
+	n, err := interp.ExecProgram(b.prog, b.config)
+	if err != nil || n != 0 {
+		log.Fatalf("%s: exit status %d: %v", b.name, n, err)
+	}
+	if !bytes.Equal(b.res.Bytes(), b.expected) {
+		log.Fatalf("%s: unexpected output (%d bytes, expected %d)", b.name, b.res.Len(), len(b.expected))
+	}
+	b.res.Reset()
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestUserAwk(t *testing.T) {
+	dir := t.TempDir()
+	content := map[string]string{
+		"sum.awk": "{ s += $2 } END { print NR, s }\n",
+		"in.txt":  "a 1\nb 2\nc 3\n",
+	}
+	for name, c := range content {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(c), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() {
+		delete(workloadRegistry, "awk:sum")
+		delete(workloadRegistry, "awk:bad")
+	})
+
+	// A wrong expected hash is rejected
+	bad := UserAwk{Name: "bad", Script: "sum.awk", Input: "in.txt", SHA256: "0123"}
+	if _, err := bad.register(dir); err == nil {
+		t.Error("wrong hash should be rejected")
+	}
+	if _, ok := workloadRegistry["awk:bad"]; ok {
+		t.Error("rejected workload should not be registered")
+	}
+
+	// Without expected hash, the first run is the reference
+	u := UserAwk{Name: "sum", Script: "sum.awk", Input: "in.txt"}
+	name, err := u.register(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	def := workloadRegistry[name]
+	if name != "awk:sum" || !def.User || len(u.SHA256) != 64 || def.Digest != awkDigest([]byte(content["sum.awk"]), []byte(content["in.txt"])) {
+		t.Fatalf("unexpected registration: %s %+v %s", name, def, u.SHA256)
+	}
+	if _, err := u.register(dir); err == nil {
+		t.Error("duplicate workload should be rejected")
+	}
+
+	// Different scripts with the same output are different workloads
+	if d := awkDigest([]byte("END { print 3, 6 }\n"), nil); d == def.Digest {
+		t.Errorf("same digest for different scripts: %s", d)
+	}
+	if awkDigest([]byte("ab"), []byte("c")) == awkDigest([]byte("a"), []byte("bc")) {
+		t.Error("the digest should separate the script from the input")
+	}
+
+	// User workloads are not part of the standard benchmark
+	if !canonicalMix().Canonical() || len(builtinWorkloads()) != 14 {
+		t.Errorf("user workload in the canonical mix")
+	}
+	m := WorkloadMix{{Name: "json", Weight: 1}, {Name: name, Weight: 1}}
+	if l := m.Label(); l != "json=1,awk:sum@"+def.Digest+"=1" {
+		t.Errorf("label=%s", l)
+	}
+
+	// The script is parsed once, and its output is checked at each run
+	b := def.New()
+	for range 3 {
+		b.Run()
+	}
+	if out := b.(*BenchUserAwk).res.Len(); out != 0 {
+		t.Errorf("output not reset: %d bytes", out)
+	}
+}
+
+func TestUserAwkSandbox(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "exec.awk")
+	if err := os.WriteFile(script, []byte(`BEGIN { system("true") }`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	u := UserAwk{Name: "exec", Script: script}
+	if _, err := u.register(""); err == nil || !strings.Contains(err.Error(), "awk:exec") {
+		t.Errorf("commands should not be allowed: %v", err)
+	}
+	delete(workloadRegistry, "awk:exec")
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+)
+
+// Scalability models
+const (
+	ModelUSL    = "usl"
+	ModelAmdahl = "amdahl"
+)
+
+// ScalabilityFit contains the coefficients of a scalability model fitted on
+// throughput measured at several concurrency levels N:
+//
+//	USL:    X(N) = λN / (1 + σ(N-1) + κN(N-1))
+//	Amdahl: X(N) = λN / (1 + σ(N-1))
+//
+// σ is the contention coefficient, κ the coherency coefficient, and λ the
+// throughput of a single worker.
+type ScalabilityFit struct {
+	Model          string  `json:"model"`
+	Lambda         float64 `json:"lambda"`
+	Sigma          float64 `json:"sigma"`
+	Kappa          float64 `json:"kappa"`
+	R2             float64 `json:"r2"`
+	RMSE           float64 `json:"rmse"`
+	PeakWorkers    float64 `json:"peak_workers,omitempty"`
+	PeakThroughput float64 `json:"peak_throughput,omitempty"`
+}
+
+// Predict returns the throughput predicted by the model for n workers
+func (f *ScalabilityFit) Predict(n float64) float64 {
+	return f.Lambda * n / (1.0 + f.Sigma*(n-1.0) + f.Kappa*n*(n-1.0))
+}
+
+// FitScalability fits a scalability model on the scaling points by non-linear
+// least squares (Levenberg-Marquardt). The coefficients are constrained to be
+// non negative.
+func FitScalability(model string, points []ScalingPoint) (*ScalabilityFit, error) {
+
+	// The number of parameters depends on the model
+	np := 3
+	if model == ModelAmdahl {
+		np = 2
+	}
+	if len(points) < np {
+		return nil, fmt.Errorf("%s model requires at least %d concurrency levels (%d available)", model, np, len(points))
+	}
+	ns := make([]float64, len(points))
+	xs := make([]float64, len(points))
+	for i, pt := range points {
+		ns[i], xs[i] = float64(pt.Workers), pt.Throughput
+	}
+
+	// The error surface is not convex: start from several initial guesses and
+	// keep the best fit. The USL includes Amdahl's law as a special case, so the
+	// Amdahl fit is also a starting point of the USL fit.
+	var starts [][]float64
+	for _, sigma := range []float64{0.0, 0.01, 0.1, 0.5} {
+		starts = append(starts, []float64{xs[0] / ns[0], sigma})
+	}
+	if np == 3 {
+		amdahl, err := FitScalability(ModelAmdahl, points)
+		if err != nil {
+			return nil, err
+		}
+		for i := range starts {
+			for _, kappa := range []float64{0.0001, 0.01} {
+				starts = append(starts, []float64{starts[i][0], starts[i][1], kappa})
+			}
+		}
+		starts = append(starts[4:], []float64{amdahl.Lambda, amdahl.Sigma, 0.0})
+	}
+	var best []float64
+	cur := math.Inf(1)
+	for _, p := range starts {
+		if p, e := levenbergMarquardt(ns, xs, p); e < cur {
+			best, cur = p, e
+		}
+	}
+	res := &ScalabilityFit{Model: model, Lambda: best[0], Sigma: best[1]}
+	if np == 3 {
+		res.Kappa = best[2]
+	}
+
+	// Goodness of fit
+	avg := bench.Average(xs)
+	sst := 0.0
+	for _, x := range xs {
+		sst += (x - avg) * (x - avg)
+	}
+	if sst > 0.0 {
+		res.R2 = 1.0 - cur/sst
+	}
+	res.RMSE = math.Sqrt(cur / float64(len(xs)))
+
+	// The USL throughput peaks when the coherency cost dominates
+	if res.Kappa > 0.0 && res.Sigma < 1.0 {
+		res.PeakWorkers = math.Sqrt((1.0 - res.Sigma) / res.Kappa)
+		res.PeakThroughput = res.Predict(res.PeakWorkers)
+	}
+	return res, nil
+}
+
+// levenbergMarquardt minimizes the sum of squared residuals of the USL model
+// (Amdahl's law if only 2 parameters are given) from an initial guess. It
+// returns the coefficients and the sum of squared residuals.
+func levenbergMarquardt(ns, xs []float64, p []float64) ([]float64, float64) {
+
+	np := len(p)
+	model := func(p []float64) *ScalabilityFit {
+		res := &ScalabilityFit{Lambda: p[0], Sigma: p[1]}
+		if np == 3 {
+			res.Kappa = p[2]
+		}
+		return res
+	}
+	sse := func(p []float64) float64 {
+		fit, sum := model(p), 0.0
+		for i := range ns {
+			r := xs[i] - fit.Predict(ns[i])
+			sum += r * r
+		}
+		return sum
+	}
+
+	mu := 1.0e-3
+	cur := sse(p)
+	for iter := 0; iter < 500; iter++ {
+
+		// Build the normal equations from the Jacobian
+		jtj := make([][]float64, np)
+		for i := range jtj {
+			jtj[i] = make([]float64, np)
+		}
+		jtr := make([]float64, np)
+		fit := model(p)
+		for i, n := range ns {
+			d := 1.0 + fit.Sigma*(n-1.0) + fit.Kappa*n*(n-1.0)
+			r := xs[i] - fit.Predict(n)
+			j := []float64{n / d, -fit.Lambda * n * (n - 1.0) / (d * d), -fit.Lambda * n * n * (n - 1.0) / (d * d)}[:np]
+			for a := 0; a < np; a++ {
+				jtr[a] += j[a] * r
+				for b := 0; b < np; b++ {
+					jtj[a][b] += j[a] * j[b]
+				}
+			}
+		}
+
+		// Try steps with an increasing damping until the error decreases.
+		// The coefficients are kept non negative.
+		improved := false
+		for mu < 1.0e12 {
+			m := make([][]float64, np)
+			for a := range m {
+				m[a] = append([]float64(nil), jtj[a]...)
+				m[a][a] += mu * math.Max(jtj[a][a], 1.0e-12)
+			}
+			delta, err := solveLinear(m, jtr)
+			if err != nil {
+				mu *= 10.0
+				continue
+			}
+			next := make([]float64, np)
+			for a := range next {
+				next[a] = math.Max(p[a]+delta[a], 0.0)
+			}
+			if e := sse(next); e < cur {
+				improved = cur-e > 1.0e-12*cur
+				p, cur = next, e
+				mu = math.Max(mu/10.0, 1.0e-12)
+				break
+			}
+			mu *= 10.0
+		}
+		if !improved {
+			break
+		}
+	}
+	return p, cur
+}
+
+// solveLinear solves a small linear system by Gaussian elimination with partial pivoting
+func solveLinear(m [][]float64, v []float64) ([]float64, error) {
+	n := len(v)
+	b := append([]float64(nil), v...)
+	for c := 0; c < n; c++ {
+		piv := c
+		for r := c + 1; r < n; r++ {
+			if math.Abs(m[r][c]) > math.Abs(m[piv][c]) {
+				piv = r
+			}
+		}
+		if math.Abs(m[piv][c]) < 1.0e-300 {
+			return nil, errors.New("singular system")
+		}
+		m[c], m[piv] = m[piv], m[c]
+		b[c], b[piv] = b[piv], b[c]
+		for r := c + 1; r < n; r++ {
+			k := m[r][c] / m[c][c]
+			for j := c; j < n; j++ {
+				m[r][j] -= k * m[c][j]
+			}
+			b[r] -= k * b[c]
+		}
+	}
+	res := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for j := r + 1; j < n; j++ {
+			s -= m[r][j] * res[j]
+		}
+		res[r] = s / m[r][r]
+	}
+	return res, nil
+}
+
+// fitResults fits the scalability models on the result files given as arguments
+func fitResults(args []string) error {
+
+	if len(args) == 0 {
+		return errors.New("at least one result file is expected to fit")
+	}
+	for _, name := range args {
+		rf, err := LoadResult(name)
+		if err != nil {
+			return err
+		}
+		log.Printf("Scalability of %s (%s)", name, describeHeader(rf.Header))
+		log.Print()
+		points := NewScaling(rf.Map())
+		displayScaling(points)
+		for _, fit := range fitModels(points) {
+			displayFit(fit)
+		}
+	}
+	return nil
+}
+
+// fitModels fits all the scalability models, and displays the models which cannot be fitted
+func fitModels(points []ScalingPoint) []*ScalabilityFit {
+	var res []*ScalabilityFit
+	for _, model := range []string{ModelUSL, ModelAmdahl} {
+		fit, err := FitScalability(model, points)
+		if err != nil {
+			log.Printf("Cannot fit %s: %v", model, err)
+			log.Print()
+			continue
+		}
+		res = append(res, fit)
+	}
+	return res
+}
+
+// displayFit displays the coefficients of a scalability model
+func displayFit(f *ScalabilityFit) {
+	switch f.Model {
+	case ModelUSL:
+		log.Print("Universal Scalability Law")
+	case ModelAmdahl:
+		log.Print("Amdahl's law")
+	}
+	log.Printf("         Lambda: %.6f", f.Lambda)
+	log.Printf("     Contention: %.6f (sigma)", f.Sigma)
+	if f.Model == ModelUSL {
+		log.Printf("      Coherency: %.6f (kappa)", f.Kappa)
+	}
+	log.Printf("             R2: %.6f", f.R2)
+	log.Printf("           RMSE: %.6f", f.RMSE)
+	switch {
+	case f.PeakWorkers > 0.0:
+		log.Printf("   Peak workers: %.1f", f.PeakWorkers)
+		log.Printf("Peak throughput: %.6f", f.PeakThroughput)
+	case f.Sigma > 0.0:
+		log.Printf(" Max throughput: %.6f (asymptote)", f.Lambda/f.Sigma)
+	}
+	log.Print()
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFitScalability(t *testing.T) {
+
+	// Synthetic USL data without noise
+	ref := &ScalabilityFit{Lambda: 100.0, Sigma: 0.05, Kappa: 0.001}
+	var points []ScalingPoint
+	for _, n := range []int{1, 2, 4, 8, 16, 32, 64} {
+		points = append(points, ScalingPoint{Workers: n, Throughput: ref.Predict(float64(n))})
+	}
+
+	usl, err := FitScalability(ModelUSL, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(usl.Lambda-100.0) > 1e-3 || math.Abs(usl.Sigma-0.05) > 1e-5 || math.Abs(usl.Kappa-0.001) > 1e-6 {
+		t.Errorf("unexpected USL coefficients: %+v", usl)
+	}
+	if usl.R2 < 0.999999 {
+		t.Errorf("unexpected R2: %v", usl.R2)
+	}
+	if peak := math.Sqrt(0.95 / 0.001); math.Abs(usl.PeakWorkers-peak) > 1e-2 {
+		t.Errorf("peak workers=%v, expected %v", usl.PeakWorkers, peak)
+	}
+
+	// Amdahl cannot model the retrograde part of the curve
+	amdahl, err := FitScalability(ModelAmdahl, points)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amdahl.Kappa != 0.0 || amdahl.PeakWorkers != 0.0 || amdahl.R2 >= usl.R2 {
+		t.Errorf("unexpected Amdahl fit: %+v", amdahl)
+	}
+
+	// Not enough points
+	if _, err := FitScalability(ModelUSL, points[:2]); err == nil {
+		t.Error("error expected with 2 points")
+	}
+}
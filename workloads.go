@@ -0,0 +1,215 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/AmadeusITGroup/cpubench1a/bench"
+	"github.com/AmadeusITGroup/cpubench1a/bench/workloads"
+)
+
+// WorkloadDef is the registration of a benchmark algorithm. The rank is the
+// position of the algorithm in the transaction, and the weight is its default
+// number of runs per transaction. User workloads are not part of the standard
+// benchmark, and their digest identifies their content in the results.
+type WorkloadDef struct {
+	Rank     int
+	Name     string
+	Category string
+	Weight   int
+	User     bool
+	Digest   string
+	New      func() bench.Benchmark
+}
+
+// workloadRegistry contains the registered algorithms, indexed by name
+var workloadRegistry = map[string]WorkloadDef{}
+
+// init registers the algorithms of the standard benchmark: their rank is
+// their position in the transaction
+func init() {
+	for i, def := range workloads.Defs() {
+		registerWorkload(WorkloadDef{Rank: i + 1, Name: def.Name, Category: def.Category, Weight: def.Weight, New: def.New})
+	}
+}
+
+// registerWorkload registers an algorithm. The names and the ranks must be
+// unique, so the order of the transaction is deterministic.
+func registerWorkload(def WorkloadDef) {
+	for _, x := range workloadRegistry {
+		if x.Name == def.Name || x.Rank == def.Rank {
+			panic(fmt.Sprintf("workload %s registered with the name or rank of %s", def.Name, x.Name))
+		}
+	}
+	workloadRegistry[def.Name] = def
+}
+
+// registeredWorkloads returns the registered algorithms in the order of the transaction
+func registeredWorkloads() []WorkloadDef {
+	res := make([]WorkloadDef, 0, len(workloadRegistry))
+	for _, def := range workloadRegistry {
+		res = append(res, def)
+	}
+	slices.SortStableFunc(res, func(a, b WorkloadDef) int {
+		return cmp.Or(cmp.Compare(a.Rank, b.Rank), strings.Compare(a.Name, b.Name))
+	})
+	return res
+}
+
+// MixEntry is an algorithm selected in a workload mix, with its number of runs per transaction
+type MixEntry struct {
+	Name   string `json:"name"`
+	Weight int    `json:"weight"`
+}
+
+// WorkloadMix is the list of algorithms run at each transaction, in the order of the transaction
+type WorkloadMix []MixEntry
+
+// workloadMix is the mix selected with the -workloads option
+var workloadMix WorkloadMix
+
+// builtinWorkloads returns the algorithms of the standard benchmark, in the order of the transaction
+func builtinWorkloads() []WorkloadDef {
+	return slices.DeleteFunc(registeredWorkloads(), func(def WorkloadDef) bool { return def.User })
+}
+
+// canonicalMix returns the mix of the standard benchmark: all the algorithms
+// with their default weight
+func canonicalMix() WorkloadMix {
+	var res WorkloadMix
+	for _, def := range builtinWorkloads() {
+		res = append(res, MixEntry{Name: def.Name, Weight: def.Weight})
+	}
+	return res
+}
+
+// ParseMix builds a workload mix from a comma separated list of terms. A term
+// is "all", the name of an algorithm, or a category, optionally followed by
+// =weight to set the number of runs per transaction. A term prefixed by "-"
+// excludes the algorithms. The selection starts with all the algorithms if
+// there are only exclusions, and is empty otherwise. User workloads are not
+// selected by this list.
+func ParseMix(spec string) (WorkloadMix, error) {
+
+	defs := builtinWorkloads()
+	if strings.TrimSpace(spec) == "" {
+		return canonicalMix(), nil
+	}
+	terms := strings.Split(spec, ",")
+	weights := map[string]int{}
+	if !slices.ContainsFunc(terms, func(t string) bool { return !strings.HasPrefix(strings.TrimSpace(t), "-") }) {
+		for _, def := range defs {
+			weights[def.Name] = def.Weight
+		}
+	}
+
+	for _, t := range terms {
+		t = strings.TrimSpace(t)
+		target, exclude := strings.CutPrefix(t, "-")
+		target, w, hasWeight := strings.Cut(target, "=")
+		weight := 0
+		if hasWeight {
+			n, err := strconv.Atoi(w)
+			if err != nil || n < 1 || exclude {
+				return nil, fmt.Errorf("invalid workload term %q (weight must be a positive integer)", t)
+			}
+			weight = n
+		}
+
+		// The names of the algorithms have precedence over the categories
+		var selected []WorkloadDef
+		for _, def := range defs {
+			if target == "all" || target == def.Name {
+				selected = append(selected, def)
+			}
+		}
+		if len(selected) == 0 {
+			for _, def := range defs {
+				if target == def.Category {
+					selected = append(selected, def)
+				}
+			}
+		}
+		if len(selected) == 0 {
+			return nil, fmt.Errorf("unknown workload or category %q (available: %s)", target, describeWorkloads(defs))
+		}
+		for _, def := range selected {
+			switch {
+			case exclude:
+				delete(weights, def.Name)
+			case hasWeight:
+				weights[def.Name] = weight
+			default:
+				weights[def.Name] = def.Weight
+			}
+		}
+	}
+
+	var res WorkloadMix
+	for _, def := range defs {
+		if w, ok := weights[def.Name]; ok {
+			res = append(res, MixEntry{Name: def.Name, Weight: w})
+		}
+	}
+	if len(res) == 0 {
+		return nil, fmt.Errorf("no workload selected by %q", spec)
+	}
+	return res, nil
+}
+
+// describeWorkloads lists the algorithms with their category
+func describeWorkloads(defs []WorkloadDef) string {
+	var b strings.Builder
+	for i, def := range defs {
+		if i > 0 {
+			b.WriteString(", ")
+		}
+		fmt.Fprintf(&b, "%s/%s", def.Name, def.Category)
+	}
+	return b.String()
+}
+
+// String returns the mix as a list of name=weight terms, which can be used
+// with the -workloads option
+func (m WorkloadMix) String() string {
+	terms := make([]string, len(m))
+	for i, e := range m {
+		terms[i] = e.Name + "=" + strconv.Itoa(e.Weight)
+	}
+	return strings.Join(terms, ",")
+}
+
+// Canonical returns true if the mix is the one of the standard benchmark
+func (m WorkloadMix) Canonical() bool {
+	return slices.Equal(m, canonicalMix())
+}
+
+// Label returns the mix as recorded with the results: empty for the
+// canonical mix, so custom mixes are never confused with the standard
+// benchmark. The user workloads are identified by their digest.
+func (m WorkloadMix) Label() string {
+	if m.Canonical() {
+		return ""
+	}
+	terms := make([]string, len(m))
+	for i, e := range m {
+		terms[i] = e.Name
+		if d := workloadRegistry[e.Name].Digest; d != "" {
+			terms[i] += "@" + d
+		}
+		terms[i] += "=" + strconv.Itoa(e.Weight)
+	}
+	return strings.Join(terms, ",")
+}
+
+// Workloads builds the algorithms of the mix for a worker
+func (m WorkloadMix) Workloads() []bench.Workload {
+	res := make([]bench.Workload, len(m))
+	for i, e := range m {
+		res[i] = bench.Workload{Name: e.Name, Bench: workloadRegistry[e.Name].New(), Weight: e.Weight}
+	}
+	return res
+}
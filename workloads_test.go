@@ -0,0 +1,72 @@
+package main
+
+import "testing"
+
+func TestRegistry(t *testing.T) {
+	defs := registeredWorkloads()
+	if len(defs) != 14 || defs[0].Name != "compression" || defs[13].Name != "haversine" {
+		t.Fatalf("unexpected registry: %v", describeWorkloads(defs))
+	}
+	for i, def := range defs {
+		if def.Rank != i+1 || def.Weight != 1 || def.Category == "" || def.New == nil {
+			t.Errorf("unexpected registration: %+v", def)
+		}
+	}
+	if m := canonicalMix(); !m.Canonical() || m.Label() != "" {
+		t.Errorf("canonical mix %s is not canonical", m)
+	}
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		spec     string
+		expected string
+	}{
+		{"crypto,memory", "memory=1,crypto=1"},
+		{"crypto=2, memory", "memory=1,crypto=2"},
+		{"text", "awk=1,json=1,logging=1"},
+		{"text,-json", "awk=1,logging=1"},
+		{"all,json=3,-codec,-data,-compute", "awk=1,json=3,logging=1"},
+	}
+	for _, tt := range tests {
+		m, err := ParseMix(tt.spec)
+		if err != nil {
+			t.Errorf("ParseMix(%q): %v", tt.spec, err)
+			continue
+		}
+		if m.String() != tt.expected || m.Canonical() {
+			t.Errorf("ParseMix(%q) = %s, expected %s", tt.spec, m, tt.expected)
+		}
+	}
+
+	// Exclusions only start from all the algorithms
+	m, err := ParseMix("-awk,-image")
+	if err != nil || len(m) != 12 || m.Canonical() {
+		t.Errorf("ParseMix(-awk,-image) = %s, %v", m, err)
+	}
+	if m, err := ParseMix(""); err != nil || !m.Canonical() {
+		t.Errorf("empty mix should be canonical: %s, %v", m, err)
+	}
+
+	for _, spec := range []string{"unknown", "json=0", "json=x", "-json=2", "-all"} {
+		if _, err := ParseMix(spec); err == nil {
+			t.Errorf("ParseMix(%q) should fail", spec)
+		}
+	}
+}
+
+func TestRegisterDuplicate(t *testing.T) {
+	for _, def := range []WorkloadDef{{Name: "json", Rank: 100}, {Name: "other", Rank: 4}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("duplicate registration %+v should panic", def)
+				}
+			}()
+			registerWorkload(def)
+		}()
+	}
+	if _, ok := workloadRegistry["other"]; ok {
+		t.Error("duplicate rank should not be registered")
+	}
+}